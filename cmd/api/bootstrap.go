@@ -0,0 +1,148 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+
+	"github.thiagohmm.com.br/cargaparcial/domain/services"
+	"github.thiagohmm.com.br/cargaparcial/domain/validation"
+	"github.thiagohmm.com.br/cargaparcial/infrastructure/config"
+	"github.thiagohmm.com.br/cargaparcial/infrastructure/database"
+	"github.thiagohmm.com.br/cargaparcial/infrastructure/queue"
+	"github.thiagohmm.com.br/cargaparcial/infrastructure/repository"
+	"github.thiagohmm.com.br/cargaparcial/usecase"
+)
+
+// appDeps agrupa as dependências compartilhadas pelos subcomandos
+type appDeps struct {
+	cfg                    *config.Conf
+	db                     *sql.DB
+	queueService           services.QueueService
+	processProductsUseCase *usecase.ProcessProductsUseCase
+}
+
+// newAppDeps carrega a configuração, conecta ao banco e monta o use case
+func newAppDeps() (*appDeps, error) {
+	ambiguityPolicy, err := usecase.ParseAmbiguityPolicy(ambiguousEAN)
+	if err != nil {
+		return nil, err
+	}
+
+	gtinWidth, err := validation.ParseGTINWidth(eanWidth)
+	if err != nil {
+		return nil, err
+	}
+
+	if dbRetryAttempts < 1 || dbRetryDelay < 0 || dbRetryBudget < 0 {
+		return nil, fmt.Errorf("política de novas tentativas inválida: --db-retry-attempts deve ser ao menos 1 e --db-retry-delay/--db-retry-budget não podem ser negativos")
+	}
+
+	// Carregar configurações usando Viper
+	cfg, err := config.LoadConfig(".")
+	if err != nil {
+		return nil, fmt.Errorf("erro ao carregar configurações: %w", err)
+	}
+
+	// A flag --unlink-strategy tem prioridade sobre UNLINK_STRATEGY
+	strategy := unlinkStrategy
+	if strategy == "" {
+		strategy = cfg.UnlinkStrategy
+	}
+	if strategy == "" {
+		strategy = string(usecase.UnlinkDeactivate)
+	}
+	parsedStrategy, err := usecase.ParseUnlinkStrategy(strategy)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := openDatabase(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Inicializar repositórios
+	dealerRepo := repository.NewDealerRepository(db)
+	productRepo := repository.NewProductRepository(db)
+	productDealerRepo := repository.NewProductDealerRepository(db)
+	productIntegrationRepo := repository.NewProductIntegrationStagingRepository(db)
+
+	// Inicializar serviço de fila RabbitMQ
+	queueService, err := queue.NewQueueService(cfg.ENV_RABBITMQ)
+	if err != nil {
+		log.Printf("⚠️  Erro ao inicializar serviço de fila: %v", err)
+		log.Println("Continuando com fila simulada...")
+	}
+
+	// Inicializar use case
+	processProductsUseCase := usecase.NewProcessProductsUseCase(
+		dealerRepo,
+		productRepo,
+		productDealerRepo,
+		productIntegrationRepo,
+		queueService,
+	)
+
+	// Configurar número de workers se especificado
+	if maxWorkers > 0 {
+		processProductsUseCase.SetMaxWorkers(maxWorkers)
+		log.Printf("✓ Configurado para usar %d workers", maxWorkers)
+	}
+
+	processProductsUseCase.SetAmbiguityPolicy(ambiguityPolicy)
+	processProductsUseCase.SetGTINOptions(validation.GTINOptions{
+		Width:          gtinWidth,
+		SkipValidation: skipEANValidation,
+	})
+	processProductsUseCase.SetIBMOptions(ibmOptions())
+	processProductsUseCase.SetUnlinkStrategy(parsedStrategy)
+	processProductsUseCase.SetSyncMaxRemoval(syncMaxRemoval)
+	processProductsUseCase.SetRetryPolicy(usecase.RetryPolicy{
+		MaxAttempts: dbRetryAttempts,
+		BaseDelay:   dbRetryDelay,
+		MaxDelay:    usecase.DefaultRetryPolicy.MaxDelay,
+		Budget:      dbRetryBudget,
+	})
+
+	return &appDeps{
+		cfg:                    cfg,
+		db:                     db,
+		queueService:           queueService,
+		processProductsUseCase: processProductsUseCase,
+	}, nil
+}
+
+// openDatabase conecta ao banco de dados da configuração
+func openDatabase(cfg *config.Conf) (*sql.DB, error) {
+	dbConfig := database.Config{
+		Host:        cfg.Host,
+		Port:        cfg.Port,
+		ServiceName: cfg.ServiceName,
+		User:        cfg.DBUser,
+		Password:    cfg.DBPassword,
+		Schema:      cfg.DBSchema,
+		Driver:      cfg.DBDriver,
+	}
+
+	db, err := database.NewConnection(dbConfig)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao conectar ao banco de dados: %w", err)
+	}
+
+	log.Println("✓ Conexão com banco de dados estabelecida")
+	return db, nil
+}
+
+// Close libera a conexão com o banco e com a fila
+func (d *appDeps) Close() {
+	if closer, ok := d.queueService.(interface{ Close() error }); ok {
+		if err := closer.Close(); err != nil {
+			log.Printf("Erro ao fechar serviço de fila: %v", err)
+		}
+	}
+
+	if err := d.db.Close(); err != nil {
+		log.Printf("Erro ao fechar conexão com banco de dados: %v", err)
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+	"github.thiagohmm.com.br/cargaparcial/infrastructure/file"
+	"github.thiagohmm.com.br/cargaparcial/usecase"
+	"github.thiagohmm.com.br/cargaparcial/usecase/dto"
+)
+
+var (
+	diffOutput        string
+	diffXLSX          string
+	diffIncludeAbsent bool
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Mostra o que a planilha mudaria em cada loja, sem gravar nada",
+	Long: `Compara os pares IBM/EAN do arquivo com as relações ativas de cada loja no banco
+e lista, por loja, os EANs já vinculados, os que seriam vinculados e os desconhecidos
+(inválidos ou sem produto). Com --include-absent, lista também os produtos vinculados
+à loja que não estão no arquivo. Apenas consultas são feitas no banco.`,
+	Run: runDiff,
+}
+
+func init() {
+	diffCmd.Flags().StringVarP(&excelFile, "excel", "e", "", "Arquivo Excel (.xlsx) ou CSV/TSV com colunas de IBM e EAN")
+	diffCmd.Flags().StringVarP(&diffOutput, "output", "o", "diff.json", "Arquivo JSON com o diff por loja")
+	diffCmd.Flags().StringVar(&diffXLSX, "xlsx", "", "Grava também o diff em Excel no arquivo informado")
+	diffCmd.Flags().BoolVar(&diffIncludeAbsent, "include-absent", false, "Lista os produtos vinculados às lojas que não estão no arquivo")
+	addTableFlags(diffCmd)
+	_ = diffCmd.MarkFlagRequired("excel")
+
+	rootCmd.AddCommand(diffCmd)
+}
+
+func runDiff(cmd *cobra.Command, args []string) {
+	log.Printf("=== Carga Parcial - Diff do Arquivo %s ===", excelFile)
+
+	deps, err := newAppDeps()
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	defer deps.Close()
+
+	data, err := readTableFile(excelFile, tableOptions(deps.cfg))
+	if err != nil {
+		log.Fatalf("Erro ao ler arquivo %s: %v", excelFile, err)
+	}
+	logInputReport(data.Report, false)
+
+	input := dto.ProcessProductsInput{
+		IBMCodes:      data.IBMCodes,
+		ProductCodes:  data.ProductCodes,
+		IBMToProducts: data.IBMToProducts,
+	}
+
+	// Ctrl+C (SIGINT) ou SIGTERM interrompem as consultas; o diff é apenas leitura
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	diff, err := deps.processProductsUseCase.Diff(ctx, input, usecase.DiffOptions{IncludeAbsent: diffIncludeAbsent})
+	if err != nil {
+		log.Fatalf("Erro ao calcular diff: %v", err)
+	}
+
+	logDiffSummary(diff)
+
+	diffJSON, err := json.MarshalIndent(diff, "", "  ")
+	if err != nil {
+		log.Fatalf("Erro ao gerar JSON do diff: %v", err)
+	}
+	if err := os.WriteFile(diffOutput, diffJSON, 0644); err != nil {
+		log.Fatalf("Erro ao salvar %s: %v", diffOutput, err)
+	}
+	log.Printf("✓ Diff salvo em %s", diffOutput)
+
+	if diffXLSX != "" {
+		if err := file.WriteDiffXLSX(diffXLSX, diff); err != nil {
+			log.Fatalf("Erro ao gerar Excel do diff: %v", err)
+		}
+		log.Printf("✓ Diff salvo em %s", diffXLSX)
+	}
+}
+
+// logDiffSummary exibe os totais do diff somados entre as lojas
+func logDiffSummary(diff *dto.DiffOutput) {
+	var linked, toLink, unknown, ambiguous, absent int
+	for _, store := range diff.Stores {
+		linked += len(store.Linked)
+		toLink += len(store.ToLink)
+		unknown += len(store.Unknown)
+		ambiguous += len(store.Ambiguous)
+		absent += len(store.Absent)
+	}
+
+	log.Println("=== Diff ===")
+	log.Printf("Lojas: %d (%d IBMs sem revendedor)", len(diff.Stores), len(diff.UnknownStores))
+	log.Printf("EANs já vinculados: %d", linked)
+	log.Printf("EANs a vincular: %d", toLink)
+	log.Printf("EANs desconhecidos: %d (%d inválidos)", unknown, len(diff.InvalidEANs))
+	if ambiguous > 0 {
+		log.Printf("EANs ambíguos: %d", ambiguous)
+	}
+	if diffIncludeAbsent {
+		log.Printf("Produtos vinculados ausentes no arquivo: %d", absent)
+	}
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+	"github.thiagohmm.com.br/cargaparcial/domain/validation"
+	"github.thiagohmm.com.br/cargaparcial/infrastructure/config"
+	"github.thiagohmm.com.br/cargaparcial/infrastructure/file"
+	"github.thiagohmm.com.br/cargaparcial/infrastructure/repository"
+)
+
+// inspectListLimit é o número máximo de itens de cada lista exibidos no log
+const inspectListLimit = 20
+
+var (
+	inspectJSON      string
+	inspectCheckIBMs bool
+)
+
+var inspectCmd = &cobra.Command{
+	Use:   "inspect <arquivo>",
+	Short: "Analisa o arquivo de entrada sem acessar o banco",
+	Long: `Lê o arquivo Excel ou CSV/TSV e exibe a análise prévia (pre-flight): linhas lidas,
+linhas ignoradas com número e motivo, pares IBM/EAN repetidos, lojas sem produtos,
+contagem por loja e o total de jobs após a deduplicação. Nenhum acesso ao banco é feito,
+exceto com --check-ibms, que consulta os IBMs no cadastro de Revendedor e sugere IBMs
+parecidos para os não encontrados.`,
+	Args: cobra.ExactArgs(1),
+	Run:  runInspect,
+}
+
+func init() {
+	inspectCmd.Flags().StringVar(&inspectJSON, "json", "", "Grava o relatório completo em JSON no arquivo informado")
+	inspectCmd.Flags().BoolVar(&inspectCheckIBMs, "check-ibms", false, "Consulta os IBMs no banco e sugere IBMs parecidos para os não encontrados")
+	addTableFlags(inspectCmd)
+
+	rootCmd.AddCommand(inspectCmd)
+}
+
+func runInspect(cmd *cobra.Command, args []string) {
+	filename := args[0]
+	log.Printf("=== Carga Parcial - Análise do Arquivo %s ===", filename)
+
+	// A configuração só é usada para os nomes de coluna (INPUT_IBM_COLUMNS/INPUT_EAN_COLUMNS)
+	// e, com --check-ibms, para a conexão com o banco
+	cfg, cfgErr := config.LoadConfig(".")
+	if cfgErr != nil && !inspectCheckIBMs {
+		log.Printf("⚠️  Configuração incompleta (ignorada na análise): %v", cfgErr)
+	}
+
+	data, err := readTableFile(filename, tableOptions(cfg))
+	if err != nil {
+		log.Fatalf("Erro ao ler arquivo %s: %v", filename, err)
+	}
+
+	if inspectCheckIBMs {
+		if cfgErr != nil {
+			log.Fatalf("--check-ibms exige a configuração do banco de dados: %v", cfgErr)
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		if err := checkIBMs(ctx, cfg, data.Report, data.IBMCodes); err != nil {
+			log.Fatalf("Erro ao verificar IBMs: %v", err)
+		}
+	}
+
+	logInputReport(data.Report, true)
+
+	if inspectJSON != "" {
+		reportJSON, err := json.MarshalIndent(data.Report, "", "  ")
+		if err != nil {
+			log.Fatalf("Erro ao gerar JSON do relatório: %v", err)
+		}
+		if err := os.WriteFile(inspectJSON, reportJSON, 0644); err != nil {
+			log.Fatalf("Erro ao salvar %s: %v", inspectJSON, err)
+		}
+		log.Printf("✓ Relatório salvo em %s", inspectJSON)
+	}
+}
+
+// logInputReport exibe a análise prévia da entrada. Com detailed=true, lista as
+// linhas ignoradas, os pares repetidos e a contagem por loja.
+func logInputReport(report *file.InputReport, detailed bool) {
+	if report == nil {
+		return
+	}
+
+	log.Println("=== Análise Prévia da Entrada ===")
+	if len(report.Sheets) > 0 {
+		log.Printf("Planilhas lidas: %v", report.Sheets)
+	}
+	log.Printf("Linhas de dados: %d (%d válidas, %d ignoradas)", report.Rows, report.ValidRows, len(report.SkippedRows))
+	log.Printf("Pares repetidos: %d (%d linhas a mais)", len(report.DuplicatePairs), report.DuplicateRows())
+	log.Printf("Lojas: %d | EANs distintos: %d | Lojas sem produtos: %d", report.IBMs, report.EANs, len(report.EmptyStores))
+	log.Printf("Jobs após deduplicação: %d", report.Jobs)
+
+	if !detailed {
+		if len(report.SkippedRows) > 0 || len(report.DuplicatePairs) > 0 || len(report.EmptyStores) > 0 {
+			log.Printf("💡 Use 'cargaparcial inspect %s' para ver as linhas ignoradas e repetidas", report.File)
+		}
+		return
+	}
+
+	if len(report.SkippedRows) > 0 {
+		log.Println("Linhas ignoradas:")
+		for i, skipped := range report.SkippedRows {
+			if i == inspectListLimit {
+				log.Printf("   ... e mais %d linhas", len(report.SkippedRows)-inspectListLimit)
+				break
+			}
+			log.Printf("   - %s: %s", skipped.RowRef, skipped.Reason)
+		}
+	}
+
+	if len(report.DuplicatePairs) > 0 {
+		log.Println("Pares repetidos:")
+		for i, pair := range report.DuplicatePairs {
+			if i == inspectListLimit {
+				log.Printf("   ... e mais %d pares", len(report.DuplicatePairs)-inspectListLimit)
+				break
+			}
+			rows := make([]string, len(pair.Rows))
+			for j, ref := range pair.Rows {
+				rows[j] = ref.String()
+			}
+			log.Printf("   - IBM %s / EAN %s: %v", pair.IBM, pair.EAN, rows)
+		}
+	}
+
+	if len(report.EmptyStores) > 0 {
+		log.Printf("Lojas sem produtos: %v", report.EmptyStores)
+	}
+
+	if len(report.UnknownIBMs) > 0 {
+		log.Printf("IBMs sem revendedor cadastrado: %d", len(report.UnknownIBMs))
+		for i, unknown := range report.UnknownIBMs {
+			if i == inspectListLimit {
+				log.Printf("   ... e mais %d IBMs", len(report.UnknownIBMs)-inspectListLimit)
+				break
+			}
+			if len(unknown.Suggestions) == 0 {
+				log.Printf("   - %s (sem IBMs parecidos)", unknown.IBM)
+				continue
+			}
+			log.Printf("   - %s → você quis dizer: %v", unknown.IBM, unknown.Suggestions)
+		}
+	}
+
+	log.Println("Produtos por loja:")
+	for i, store := range report.Stores {
+		if i == inspectListLimit {
+			log.Printf("   ... e mais %d lojas (use --json para a lista completa)", len(report.Stores)-inspectListLimit)
+			break
+		}
+		log.Printf("   - IBM %s: %d linhas, %d produtos", store.IBM, store.Rows, store.Products)
+	}
+}
+
+// checkIBMs consulta os IBMs da entrada no cadastro de Revendedor e registra no
+// relatório os não encontrados, com os IBMs cadastrados mais parecidos
+func checkIBMs(ctx context.Context, cfg *config.Conf, report *file.InputReport, ibms []string) error {
+	db, err := openDatabase(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	dealerRepo := repository.NewDealerRepository(db)
+	found, err := dealerRepo.GetByIBMs(ctx, ibms)
+	if err != nil {
+		return fmt.Errorf("erro ao buscar revendedores: %w", err)
+	}
+
+	var registered []string
+	listed := false
+	for _, ibm := range ibms {
+		if _, ok := found[ibm]; ok {
+			continue
+		}
+		if !listed {
+			if registered, err = dealerRepo.ListIBMs(ctx); err != nil {
+				return fmt.Errorf("erro ao listar IBMs cadastrados: %w", err)
+			}
+			listed = true
+		}
+		report.UnknownIBMs = append(report.UnknownIBMs, file.UnknownIBM{
+			IBM:         ibm,
+			Suggestions: validation.SuggestIBMs(ibm, registered, validation.DefaultSuggestDistance, validation.DefaultSuggestLimit),
+		})
+	}
+	return nil
+}
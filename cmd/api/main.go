@@ -2,18 +2,20 @@ package main
 
 import (
 	"bufio"
-	"encoding/json"
+	"context"
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
+	"path/filepath"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
+	"github.thiagohmm.com.br/cargaparcial/domain/validation"
 	"github.thiagohmm.com.br/cargaparcial/infrastructure/config"
-	"github.thiagohmm.com.br/cargaparcial/infrastructure/database"
 	"github.thiagohmm.com.br/cargaparcial/infrastructure/file"
-	"github.thiagohmm.com.br/cargaparcial/infrastructure/queue"
-	"github.thiagohmm.com.br/cargaparcial/infrastructure/repository"
 	"github.thiagohmm.com.br/cargaparcial/usecase"
 	"github.thiagohmm.com.br/cargaparcial/usecase/dto"
 )
@@ -22,8 +24,38 @@ var (
 	ibmFile    string
 	codigoFile string
 	excelFile  string
+	csvFile    string
 	outputFile string
 	maxWorkers int
+	streamXLSX bool
+
+	reportFormats []string
+
+	ibmColumns []string
+	eanColumns []string
+	sheetName  string
+	allSheets  bool
+	headerRow  int
+
+	ambiguousEAN      string
+	eanWidth          int
+	skipEANValidation bool
+	ibmWidth          int
+	ibmStripNonDigits bool
+
+	checkpointFile string
+	resumeFile     string
+	noCheckpoint   bool
+
+	dryRun bool
+
+	operationMode  string
+	unlinkStrategy string
+	syncMaxRemoval float64
+
+	dbRetryAttempts int
+	dbRetryDelay    time.Duration
+	dbRetryBudget   int
 )
 
 var rootCmd = &cobra.Command{
@@ -38,9 +70,28 @@ processa em paralelo e gera arquivo de resultado.`,
 func init() {
 	rootCmd.Flags().StringVarP(&ibmFile, "ibm", "i", "ibm.txt", "Arquivo com códigos IBM (um por linha)")
 	rootCmd.Flags().StringVarP(&codigoFile, "codigo", "c", "codigo.txt", "Arquivo com códigos de produtos/EAN (um por linha)")
-	rootCmd.Flags().StringVarP(&excelFile, "excel", "e", "", "Arquivo Excel (.xlsx) com colunas IMBLOJA e CODIGOBARRAS")
+	rootCmd.Flags().StringVarP(&excelFile, "excel", "e", "", "Arquivo Excel (.xlsx) com colunas de IBM e EAN, ex.: IMBLOJA e CODIGOBARRAS (.csv/.tsv são lidos como CSV)")
+	rootCmd.Flags().StringVar(&csvFile, "csv", "", "Arquivo CSV/TSV com colunas de IBM e EAN, ex.: IMBLOJA e CODIGOBARRAS")
+	rootCmd.Flags().BoolVar(&streamXLSX, "stream", false, "Lê o arquivo Excel em streaming, com memória constante (recomendado para planilhas muito grandes)")
+	addTableFlags(rootCmd)
 	rootCmd.Flags().StringVarP(&outputFile, "output", "o", "resultado.json", "Arquivo de saída com resultados")
-	rootCmd.Flags().IntVarP(&maxWorkers, "workers", "w", 0, "Número de workers paralelos (0 = auto, baseado em CPUs)")
+	rootCmd.Flags().StringSliceVar(&reportFormats, "report-format", []string{"json"}, "Formatos do resultado: json, xlsx, ndjson e/ou csv (os demais formatos usam o caminho do --output com a própria extensão)")
+	rootCmd.Flags().StringVar(&checkpointFile, "checkpoint", "", "Arquivo de journal de checkpoint (padrão: <output>.checkpoint)")
+	rootCmd.Flags().StringVar(&resumeFile, "resume", "", "Retoma a execução a partir do journal informado, pulando os pares já concluídos")
+	rootCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Simula a carga: resolve revendedores, EANs e relações sem gravar nada nem publicar \"mover\"")
+	rootCmd.Flags().StringVar(&operationMode, "mode", string(usecase.ModeLink), "Modo de operação: link (vincula os pares), unlink (remove as relações dos pares) ou sync (sortimento completo de cada loja)")
+	rootCmd.Flags().StringVar(&unlinkStrategy, "unlink-strategy", "", "Remoção nos modos unlink e sync: deactivate ou delete (padrão: UNLINK_STRATEGY ou deactivate)")
+	rootCmd.Flags().Float64Var(&syncMaxRemoval, "sync-max-removal", usecase.DefaultSyncMaxRemoval, "Percentual máximo das relações ativas de uma loja que o modo sync pode remover (acima dele a execução é abortada)")
+	rootCmd.Flags().BoolVar(&noCheckpoint, "no-checkpoint", false, "Desabilita a gravação do journal de checkpoint")
+	rootCmd.PersistentFlags().IntVarP(&maxWorkers, "workers", "w", 0, "Número de workers paralelos (0 = auto, baseado em CPUs)")
+	rootCmd.PersistentFlags().IntVar(&eanWidth, "ean-width", 0, "Tamanho dos EANs gravados em EmbalagemProduto (0 = tamanho padrão do código, 8, 12, 13 ou 14)")
+	rootCmd.PersistentFlags().BoolVar(&skipEANValidation, "skip-ean-validation", false, "Não valida o dígito verificador dos EANs (códigos internos fora do padrão GTIN)")
+	rootCmd.PersistentFlags().IntVar(&ibmWidth, "ibm-width", 0, "Tamanho canônico do IBM: códigos numéricos menores recebem zeros à esquerda (ex.: 10; 0 = não completa)")
+	rootCmd.PersistentFlags().BoolVar(&ibmStripNonDigits, "ibm-strip-non-digits", false, "Remove do IBM os caracteres que não são dígitos (ex.: 0001.002.154)")
+	rootCmd.PersistentFlags().StringVar(&ambiguousEAN, "ambiguous-ean", string(usecase.AmbiguityFail), "Tratamento de EAN com mais de um produto: fail, all ou recent")
+	rootCmd.PersistentFlags().IntVar(&dbRetryAttempts, "db-retry-attempts", usecase.DefaultRetryPolicy.MaxAttempts, "Tentativas de cada operação de banco que falha por erro transitório, como ORA-03113 (1 = sem novas tentativas)")
+	rootCmd.PersistentFlags().DurationVar(&dbRetryDelay, "db-retry-delay", usecase.DefaultRetryPolicy.BaseDelay, "Espera antes da primeira nova tentativa; dobra a cada tentativa, com jitter")
+	rootCmd.PersistentFlags().IntVar(&dbRetryBudget, "db-retry-budget", usecase.DefaultRetryPolicy.Budget, "Máximo de novas tentativas somadas na execução (0 = sem limite)")
 }
 
 func main() {
@@ -53,88 +104,84 @@ func main() {
 func runProcess(cmd *cobra.Command, args []string) {
 	log.Println("=== Carga Parcial - Processador de Produtos ===")
 
-	// Verificar se está usando arquivo Excel ou arquivos TXT
-	usingExcel := excelFile != ""
-
-	if usingExcel {
-		log.Printf("Arquivo Excel: %s", excelFile)
-	} else {
-		log.Printf("Arquivo IBM: %s", ibmFile)
-		log.Printf("Arquivo Código: %s", codigoFile)
+	if err := validateReportFormats(reportFormats); err != nil {
+		log.Fatalf("%v", err)
 	}
-	log.Printf("Arquivo Saída: %s", outputFile)
 
-	// Carregar configurações usando Viper
-	cfg, err := config.LoadConfig(".")
+	mode, err := usecase.ParseOperationMode(operationMode)
 	if err != nil {
-		log.Fatalf("Erro ao carregar configurações: %v", err)
+		log.Fatalf("%v", err)
+	}
+	switch mode {
+	case usecase.ModeUnlink:
+		log.Println("🔗 Modo unlink: as relações dos pares da entrada serão removidas")
+	case usecase.ModeSync:
+		if syncMaxRemoval < 0 || syncMaxRemoval > 100 {
+			log.Fatalf("--sync-max-removal deve estar entre 0 e 100: %.1f", syncMaxRemoval)
+		}
+		log.Printf("🔄 Modo sync: relações ativas ausentes da entrada serão removidas (limite de %.1f%% por loja)", syncMaxRemoval)
 	}
 
-	// Criar configuração do banco de dados
-	dbConfig := database.Config{
-		Host:        cfg.Host,
-		Port:        cfg.Port,
-		ServiceName: cfg.ServiceName,
-		User:        cfg.DBUser,
-		Password:    cfg.DBPassword,
-		Schema:      cfg.DBSchema,
-		Driver:      cfg.DBDriver,
+	// Verificar se está usando arquivo tabular (Excel/CSV) ou arquivos TXT
+	tableFile := excelFile
+	if csvFile != "" {
+		tableFile = csvFile
 	}
+	usingTable := tableFile != ""
 
-	// Conectar ao banco de dados
-	db, err := database.NewConnection(dbConfig)
-	if err != nil {
-		log.Fatalf("Erro ao conectar ao banco de dados: %v", err)
+	// Somente o Excel é lido em streaming: CSV/TSV e TXT seriam lidos inteiros em memória
+	if streamXLSX && (!usingTable || isCSVInput(tableFile)) {
+		log.Fatalf("--stream só é suportado com arquivos Excel (--excel .xlsx); CSV/TSV e TXT são lidos inteiros em memória")
 	}
-	defer db.Close()
 
-	log.Println("✓ Conexão com banco de dados estabelecida")
+	// A simulação não usa o journal: retomar a partir dele não pularia nada e, ao
+	// terminar, removeria o ponto de retomada da execução real
+	if resumeFile != "" && dryRun {
+		log.Fatalf("--resume não pode ser usado com --dry-run: a simulação não lê nem grava o journal de checkpoint")
+	}
 
-	// Inicializar repositórios
-	dealerRepo := repository.NewDealerRepository(db)
-	productRepo := repository.NewProductRepository(db)
-	productDealerRepo := repository.NewProductDealerRepository(db)
-	productIntegrationRepo := repository.NewProductIntegrationStagingRepository(db)
+	if usingTable {
+		log.Printf("Arquivo de entrada: %s", tableFile)
+	} else {
+		log.Printf("Arquivo IBM: %s", ibmFile)
+		log.Printf("Arquivo Código: %s", codigoFile)
+	}
+	log.Printf("Arquivo Saída: %s", outputFile)
 
-	// Inicializar serviço de fila RabbitMQ
-	queueService, err := queue.NewQueueService(cfg.ENV_RABBITMQ)
+	deps, err := newAppDeps()
 	if err != nil {
-		log.Printf("⚠️  Erro ao inicializar serviço de fila: %v", err)
-		log.Println("Continuando com fila simulada...")
+		log.Fatalf("%v", err)
 	}
+	defer deps.Close()
 
-	// Inicializar use case
-	processProductsUseCase := usecase.NewProcessProductsUseCase(
-		dealerRepo,
-		productRepo,
-		productDealerRepo,
-		productIntegrationRepo,
-		queueService,
-	)
-
-	// Configurar número de workers se especificado
-	if maxWorkers > 0 {
-		processProductsUseCase.SetMaxWorkers(maxWorkers)
-		log.Printf("✓ Configurado para usar %d workers", maxWorkers)
-	}
+	processProductsUseCase := deps.processProductsUseCase
 
 	var ibmCodes []string
 	var productCodes []string
 	var ibmToProducts map[string][]string
+	var sourceRows file.PairRows
 	var totalCombinations int
 
 	// Ler arquivos de entrada
-	if usingExcel {
-		// Ler arquivo Excel
-		log.Printf("Lendo arquivo Excel: %s", excelFile)
-		xlsxData, err := file.ReadXLSX(excelFile)
+	streaming := streamXLSX
+	if streaming {
+		// A planilha é lida durante o processamento, em blocos
+		log.Printf("Lendo arquivo em streaming: %s", tableFile)
+	} else if usingTable {
+		// Ler arquivo Excel ou CSV
+		log.Printf("Lendo arquivo: %s", tableFile)
+		xlsxData, err := readTableFile(tableFile, tableOptions(deps.cfg))
 		if err != nil {
-			log.Fatalf("Erro ao ler arquivo Excel %s: %v", excelFile, err)
+			log.Fatalf("Erro ao ler arquivo %s: %v", tableFile, err)
 		}
 
+		// Pre-flight: linhas ignoradas, duplicatas e lojas sem produtos
+		logInputReport(xlsxData.Report, false)
+
 		ibmCodes = xlsxData.IBMCodes
 		productCodes = xlsxData.ProductCodes
 		ibmToProducts = xlsxData.IBMToProducts
+		sourceRows = xlsxData.Rows
 
 		log.Printf("✓ Lidos %d códigos IBM únicos", len(ibmCodes))
 		log.Printf("✓ Lidos %d códigos de produto únicos", len(productCodes))
@@ -173,37 +220,200 @@ func runProcess(cmd *cobra.Command, args []string) {
 		IBMCodes:      ibmCodes,
 		ProductCodes:  productCodes,
 		IBMToProducts: ibmToProducts, // Passa o relacionamento correto
+		SourceRows:    sourceRows.Labels(),
+	}
+
+	// Ctrl+C (SIGINT) ou SIGTERM interrompem o processamento e geram resultado parcial
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	finished := make(chan struct{})
+	defer close(finished)
+	go func() {
+		select {
+		case <-ctx.Done():
+			log.Println("⚠️  Interrupção recebida: finalizando itens em andamento (Ctrl+C novamente para abortar)")
+			stop()
+		case <-finished:
+		}
+	}()
+
+	opts := usecase.ExecuteOptions{DryRun: dryRun, Mode: mode}
+	if dryRun {
+		log.Println("🧪 Modo simulação (--dry-run): nenhuma alteração será gravada")
 	}
 
-	output, err := processProductsUseCase.Execute(input)
+	journal, err := openJournal()
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	if journal != nil {
+		opts.Journal = journal
+	}
+
+	// Formatos gravados durante a execução (ndjson, csv); sem json/xlsx os
+	// resultados não são acumulados em memória
+	sink, closers, err := openResultSinks(reportFormats)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	opts.Sink = sink
+	opts.KeepResults = keepsResults(reportFormats)
+
+	var output *dto.ProcessProductsOutput
+	if streaming {
+		output, err = executeStream(ctx, processProductsUseCase, tableFile, tableOptions(deps.cfg), opts)
+	} else {
+		output, err = processProductsUseCase.ExecuteWithOptions(ctx, input, opts)
+	}
+	if journal != nil {
+		closeJournal(journal, err == nil && !output.Cancelled && !output.DryRun)
+	}
+	for _, closer := range closers {
+		if closeErr := closer.Close(); closeErr != nil {
+			log.Printf("⚠️  %v", closeErr)
+		}
+	}
 	if err != nil {
 		log.Fatalf("Erro ao processar produtos: %v", err)
 	}
 
-	// Exibir resultados
-	log.Println("=== Processamento Concluído ===")
-	log.Printf("✓ Sucessos: %d", len(output.SuccessList))
-	log.Printf("✗ Falhas: %d", len(output.FailureList))
+	// Exibir resultados (os resumos por ação dependem das listas em memória)
+	switch {
+	case opts.DiscardsResults():
+	case output.DryRun:
+		logPlannedActions(output)
+	case output.Mode != string(usecase.ModeLink):
+		logRelationSummary(output)
+	}
+	if output.Cancelled {
+		log.Println("=== Processamento Cancelado (resultado parcial) ===")
+	} else {
+		log.Println("=== Processamento Concluído ===")
+	}
+	log.Printf("✓ Sucessos: %d", output.Totals.Ok)
+	log.Printf("✗ Falhas: %d", output.Totals.Fail)
+	if output.Totals.NotProcessed > 0 {
+		log.Printf("⏸ Não processados: %d", output.Totals.NotProcessed)
+	}
+
+	if streaming {
+		// Em streaming o total só é conhecido ao final da leitura
+		totalCombinations = output.Totals.Total()
+		log.Printf("Total de combinações processadas: %d", totalCombinations)
+	}
 
 	successRate := 0.0
 	if totalCombinations > 0 {
-		successRate = float64(len(output.SuccessList)) / float64(totalCombinations) * 100
+		successRate = float64(output.Totals.Ok) / float64(totalCombinations) * 100
 	}
 	log.Printf("Taxa de sucesso: %.2f%%", successRate)
 
-	// Salvar resultado em arquivo JSON
-	log.Printf("Salvando resultados em: %s", outputFile)
-	resultJSON, err := json.MarshalIndent(output, "", "  ")
-	if err != nil {
-		log.Fatalf("Erro ao gerar JSON de resultado: %v", err)
+	// Salvar resultado nos formatos gravados ao final da execução
+	for _, format := range reportFormats {
+		if err := writeResult(format, output); err != nil {
+			log.Fatalf("%v", err)
+		}
 	}
 
-	if err := os.WriteFile(outputFile, resultJSON, 0644); err != nil {
-		log.Fatalf("Erro ao salvar %s: %v", outputFile, err)
+	log.Println("=== Processo Finalizado ===")
+}
+
+// logPlannedActions resume as ações planejadas de uma simulação
+func logPlannedActions(output *dto.ProcessProductsOutput) {
+	counts := make(map[string]int)
+	for _, result := range output.SuccessList {
+		for _, action := range result.Planned {
+			counts[action]++
+		}
 	}
 
-	log.Printf("✓ Resultado salvo com sucesso em %s", outputFile)
-	log.Println("=== Processo Finalizado ===")
+	log.Println("=== Simulação (nada foi gravado) ===")
+	if output.Mode != string(usecase.ModeUnlink) {
+		log.Printf("Relações a criar: %d", counts[dto.ActionCreateRelation])
+		log.Printf("Relações a reativar: %d", counts[dto.ActionReactivateRelation])
+		log.Printf("Pares já vinculados: %d", counts[dto.ActionAlreadyLinked])
+	}
+	if output.Mode != string(usecase.ModeLink) {
+		log.Printf("Relações a desativar: %d", counts[dto.ActionDeactivateRelation])
+		log.Printf("Relações a excluir: %d", counts[dto.ActionDeleteRelation])
+		log.Printf("Pares já desvinculados: %d", counts[dto.ActionAlreadyUnlinked])
+		log.Printf("Pares sem relação a remover: %d", countRelations(output.FailureList, dto.RelationNotFound))
+	}
+	log.Printf("Pares que seriam enviados ao staging: %d", counts[dto.ActionStage])
+}
+
+// logRelationSummary resume as relações gravadas nos modos unlink e sync
+func logRelationSummary(output *dto.ProcessProductsOutput) {
+	if output.Mode == string(usecase.ModeSync) {
+		log.Println("=== Sincronização ===")
+		log.Printf("Relações criadas: %d", countRelations(output.SuccessList, dto.RelationCreated))
+		log.Printf("Relações reativadas: %d", countRelations(output.SuccessList, dto.RelationReactivated))
+		log.Printf("Pares inalterados: %d", countRelations(output.SuccessList, dto.RelationAlreadyActive))
+	} else {
+		log.Println("=== Desvinculação ===")
+	}
+	log.Printf("Relações desativadas: %d", countRelations(output.SuccessList, dto.RelationDeactivated))
+	log.Printf("Relações excluídas: %d", countRelations(output.SuccessList, dto.RelationDeleted))
+	if output.Mode == string(usecase.ModeUnlink) {
+		log.Printf("Relações já inativas: %d", countRelations(output.SuccessList, dto.RelationAlreadyInactive))
+		log.Printf("Relações não encontradas: %d", countRelations(output.FailureList, dto.RelationNotFound))
+	}
+}
+
+// countRelations conta os resultados com o valor de Relacao informado
+func countRelations(results []dto.ProductResultDTO, relation string) int {
+	count := 0
+	for _, result := range results {
+		if result.Relation == relation {
+			count++
+		}
+	}
+	return count
+}
+
+// closeJournal fecha o journal e, quando uma execução real terminou sem cancelamento,
+// o remove: não há mais pares a retomar
+func closeJournal(journal *file.CheckpointJournal, completed bool) {
+	if err := journal.Close(); err != nil {
+		log.Printf("⚠️  %v", err)
+		return
+	}
+	if !completed {
+		return
+	}
+	if err := journal.Remove(); err != nil {
+		log.Printf("⚠️  %v", err)
+	}
+}
+
+// openJournal abre o journal de checkpoint conforme as flags --checkpoint, --resume e --no-checkpoint
+func openJournal() (*file.CheckpointJournal, error) {
+	if resumeFile != "" {
+		journal, err := file.OpenCheckpointJournal(resumeFile, true)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao abrir journal %s: %w", resumeFile, err)
+		}
+		log.Printf("♻️  Retomando a partir do journal: %s (%d pares concluídos)", resumeFile, len(journal.Entries()))
+		return journal, nil
+	}
+
+	// Uma simulação não grava journal: retomar a partir dela pularia pares nunca gravados
+	if noCheckpoint || dryRun {
+		return nil, nil
+	}
+
+	path := checkpointFile
+	if path == "" {
+		path = outputFile + ".checkpoint"
+	}
+
+	journal, err := file.OpenCheckpointJournal(path, false)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criar journal %s: %w", path, err)
+	}
+	log.Printf("Journal de checkpoint: %s (use --resume %s para retomar)", path, path)
+	return journal, nil
 }
 
 // readLinesFromFile lê todas as linhas de um arquivo
@@ -234,3 +444,89 @@ func readLinesFromFile(filename string) ([]string, error) {
 
 	return lines, nil
 }
+
+// executeStream processa o arquivo XLSX lendo os pares em streaming
+func executeStream(ctx context.Context, uc *usecase.ProcessProductsUseCase, filename string, tableOpts file.TableOptions, opts usecase.ExecuteOptions) (*dto.ProcessProductsOutput, error) {
+	reader, err := file.OpenXLSXPairReader(filename, tableOpts)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler arquivo %s: %w", filename, err)
+	}
+	defer func() {
+		if err := reader.Close(); err != nil {
+			log.Printf("⚠️  %v", err)
+		}
+	}()
+
+	return uc.ExecuteStream(ctx, reader, opts)
+}
+
+// isCSVInput indica se o arquivo de entrada deve ser lido como CSV/TSV
+func isCSVInput(filename string) bool {
+	if csvFile != "" {
+		return true
+	}
+
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv", ".tsv":
+		return true
+	default:
+		return false
+	}
+}
+
+// readTableFile lê o arquivo de entrada como CSV/TSV (flag --csv ou extensão .csv/.tsv)
+// ou como XLSX
+func readTableFile(filename string, opts file.TableOptions) (*file.XLSXData, error) {
+	if isCSVInput(filename) {
+		return file.ReadCSVWithOptions(filename, opts)
+	}
+	return file.ReadXLSXWithOptions(filename, opts)
+}
+
+// addTableFlags registra as flags de leitura de arquivos tabulares no comando
+func addTableFlags(cmd *cobra.Command) {
+	cmd.Flags().StringSliceVar(&ibmColumns, "ibm-columns", nil, "Nomes aceitos para a coluna do IBM, em ordem de prioridade (padrão: IMBLOJA,IBM,COD_LOJA)")
+	cmd.Flags().StringSliceVar(&eanColumns, "ean-columns", nil, "Nomes aceitos para a coluna do EAN, em ordem de prioridade (padrão: CODIGOBARRAS,EAN,GTIN)")
+	cmd.Flags().StringVar(&sheetName, "sheet", "", "Planilha do Excel a ler, por nome ou índice (1 = primeira)")
+	cmd.Flags().BoolVar(&allSheets, "all-sheets", false, "Lê todas as planilhas do Excel que possuem as colunas de IBM e EAN")
+	cmd.Flags().IntVar(&headerRow, "header-row", 1, "Linha do cabeçalho, para arquivos com linhas de título acima dele")
+}
+
+// tableOptions monta as opções de leitura do arquivo tabular. Os nomes de coluna
+// informados nas flags têm prioridade sobre INPUT_IBM_COLUMNS/INPUT_EAN_COLUMNS da configuração.
+func tableOptions(cfg *config.Conf) file.TableOptions {
+	opts := file.TableOptions{
+		IBMColumns: ibmColumns,
+		EANColumns: eanColumns,
+		Sheet:      sheetName,
+		AllSheets:  allSheets,
+		HeaderRow:  headerRow,
+		IBM:        ibmOptions(),
+	}
+	if len(opts.IBMColumns) == 0 && cfg != nil {
+		opts.IBMColumns = splitList(cfg.InputIBMColumns)
+	}
+	if len(opts.EANColumns) == 0 && cfg != nil {
+		opts.EANColumns = splitList(cfg.InputEANColumns)
+	}
+	return opts
+}
+
+// ibmOptions monta a normalização de IBM configurada nas flags
+func ibmOptions() validation.IBMOptions {
+	return validation.IBMOptions{
+		Width:          ibmWidth,
+		StripNonDigits: ibmStripNonDigits,
+	}
+}
+
+// splitList separa uma lista de valores separados por vírgula, ignorando itens vazios
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
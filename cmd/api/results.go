@@ -0,0 +1,136 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.thiagohmm.com.br/cargaparcial/infrastructure/file"
+	"github.thiagohmm.com.br/cargaparcial/usecase"
+	"github.thiagohmm.com.br/cargaparcial/usecase/dto"
+)
+
+// Formatos de resultado aceitos em --report-format. json e xlsx são gravados ao final
+// da execução; ndjson e csv recebem cada resultado assim que o par é concluído.
+const (
+	formatJSON   = "json"
+	formatXLSX   = "xlsx"
+	formatNDJSON = "ndjson"
+	formatCSV    = "csv"
+)
+
+// validateReportFormats confere os formatos informados em --report-format e se
+// cada um é gravado em um arquivo diferente (ex.: -o resultado.xlsx com json e xlsx
+// gravaria os dois no mesmo arquivo)
+func validateReportFormats(formats []string) error {
+	if len(formats) == 0 {
+		return fmt.Errorf("informe ao menos um formato em --report-format (json, xlsx, ndjson ou csv)")
+	}
+
+	paths := make(map[string]string, len(formats))
+	for _, format := range formats {
+		switch format {
+		case formatJSON, formatXLSX, formatNDJSON, formatCSV:
+		default:
+			return fmt.Errorf("formato de resultado inválido: %q (use json, xlsx, ndjson ou csv)", format)
+		}
+
+		path := filepath.Clean(resultPath(format))
+		if other, ok := paths[path]; ok && other != format {
+			return fmt.Errorf("os formatos %s e %s seriam gravados no mesmo arquivo %s: use --output com outra extensão (ex.: resultado.json)", other, format, path)
+		}
+		paths[path] = format
+	}
+	return nil
+}
+
+// keepsResults indica se algum formato precisa das listas de resultados em memória
+func keepsResults(formats []string) bool {
+	return slices.Contains(formats, formatJSON) || slices.Contains(formats, formatXLSX)
+}
+
+// resultPath retorna o arquivo de um formato: o próprio --output para json e, para os
+// demais, o mesmo caminho com a extensão do --output trocada pela do formato
+func resultPath(format string) string {
+	if format == formatJSON {
+		return outputFile
+	}
+	return strings.TrimSuffix(outputFile, filepath.Ext(outputFile)) + "." + format
+}
+
+// openResultSinks abre os arquivos dos formatos gravados durante a execução.
+// Retorna o sink combinado (nil quando não há nenhum) e os arquivos a fechar ao final.
+func openResultSinks(formats []string) (usecase.ResultSink, []io.Closer, error) {
+	var sinks []usecase.ResultSink
+	var closers []io.Closer
+
+	fail := func(err error) (usecase.ResultSink, []io.Closer, error) {
+		for _, closer := range closers {
+			closer.Close()
+		}
+		return nil, nil, err
+	}
+
+	for _, format := range formats {
+		path := resultPath(format)
+		switch format {
+		case formatNDJSON:
+			sink, err := file.NewNDJSONResultSink(path)
+			if err != nil {
+				return fail(err)
+			}
+			sinks, closers = append(sinks, sink), append(closers, sink)
+		case formatCSV:
+			sink, err := file.NewCSVResultSink(path)
+			if err != nil {
+				return fail(err)
+			}
+			sinks, closers = append(sinks, sink), append(closers, sink)
+		default:
+			continue
+		}
+		log.Printf("Gravando resultados durante a execução em: %s", path)
+	}
+
+	if len(sinks) == 0 {
+		return nil, nil, nil
+	}
+	return usecase.MultiSink(sinks...), closers, nil
+}
+
+// writeResult grava o resultado ao final da execução nos formatos json e xlsx
+// (os formatos gravados durante a execução são ignorados)
+func writeResult(format string, output *dto.ProcessProductsOutput) error {
+	path := resultPath(format)
+
+	switch format {
+	case formatXLSX:
+		log.Printf("Salvando relatório Excel em: %s", path)
+		if err := file.WriteResultXLSX(path, output); err != nil {
+			return fmt.Errorf("erro ao gerar relatório Excel: %w", err)
+		}
+		log.Printf("✓ Relatório salvo com sucesso em %s", path)
+
+	case formatJSON:
+		log.Printf("Salvando resultados em: %s", path)
+		resultJSON, err := json.MarshalIndent(output, "", "  ")
+		if err != nil {
+			return fmt.Errorf("erro ao gerar JSON de resultado: %w", err)
+		}
+
+		if err := os.WriteFile(path, resultJSON, 0644); err != nil {
+			return fmt.Errorf("erro ao salvar %s: %w", path, err)
+		}
+
+		log.Printf("✓ Resultado salvo com sucesso em %s", path)
+
+	default:
+		log.Printf("✓ Resultados gravados em %s", path)
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+	"github.thiagohmm.com.br/cargaparcial/infrastructure/file"
+	"github.thiagohmm.com.br/cargaparcial/usecase"
+	"github.thiagohmm.com.br/cargaparcial/usecase/dto"
+)
+
+var (
+	retryFrom    string
+	retryOutput  string
+	retryXLSX    string
+	retryReasons []string
+)
+
+var retryCmd = &cobra.Command{
+	Use:   "retry",
+	Short: "Reprocessa as falhas de um resultado anterior",
+	Long: `Lê o arrayFail (e o arrayNaoProcessado) de um resultado JSON, seleciona os pares
+pelo código de motivo (CodigoMotivo) e os processa novamente. Por padrão apenas falhas de
+banco e pares não processados são reprocessados; falhas de cadastro como EAN_NOT_FOUND só
+mudam com correção dos dados.
+
+O resultado gravado é o anterior atualizado: os novos resultados substituem as falhas
+reprocessadas e os demais itens são mantidos.`,
+	Run: runRetry,
+}
+
+func init() {
+	retryCmd.Flags().StringVar(&retryFrom, "from", "", "Arquivo JSON de resultado de uma execução anterior")
+	retryCmd.Flags().StringVarP(&retryOutput, "output", "o", "", "Arquivo JSON com o resultado atualizado (padrão: o próprio arquivo de --from)")
+	retryCmd.Flags().StringVar(&retryXLSX, "xlsx", "", "Grava também o relatório Excel do resultado atualizado no arquivo informado")
+	retryCmd.Flags().StringSliceVar(&retryReasons, "reason", usecase.DefaultRetryReasons, "Códigos de motivo reprocessados (ex.: STAGING_SP_ERROR,CANCELLED)")
+	retryCmd.Flags().StringVar(&unlinkStrategy, "unlink-strategy", "", "Remoção das relações de resultados unlink/sync: deactivate ou delete (padrão: UNLINK_STRATEGY ou deactivate)")
+	_ = retryCmd.MarkFlagRequired("from")
+
+	rootCmd.AddCommand(retryCmd)
+}
+
+func runRetry(cmd *cobra.Command, args []string) {
+	log.Printf("=== Carga Parcial - Reprocessamento de %s ===", retryFrom)
+
+	if retryOutput == "" {
+		retryOutput = retryFrom
+	}
+
+	content, err := os.ReadFile(retryFrom)
+	if err != nil {
+		log.Fatalf("Erro ao ler %s: %v", retryFrom, err)
+	}
+	var previous dto.ProcessProductsOutput
+	if err := json.Unmarshal(content, &previous); err != nil {
+		log.Fatalf("Erro ao interpretar %s: %v", retryFrom, err)
+	}
+	if previous.DryRun {
+		log.Fatalf("%s é o resultado de uma simulação (--dry-run): não há falhas a reprocessar", retryFrom)
+	}
+
+	// Sem pares a reprocessar não é preciso conectar ao banco
+	plan := usecase.PlanRetry(&previous, usecase.RetryOptions{Reasons: retryReasons})
+	if plan.SyncRemovals > 0 && plan.Pairs() == 0 {
+		log.Printf("⚠️  %d remoções da sincronização não são reprocessadas: reexecute o sync com o sortimento completo", plan.SyncRemovals)
+	}
+	if plan.Pairs() == 0 {
+		log.Printf("Nenhuma falha com os códigos %v em %s", retryReasons, retryFrom)
+		return
+	}
+
+	deps, err := newAppDeps()
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	defer deps.Close()
+
+	// Ctrl+C (SIGINT) ou SIGTERM interrompem o reprocessamento; os pares restantes
+	// ficam em arrayNaoProcessado e podem ser reprocessados depois
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	output, err := deps.processProductsUseCase.Retry(ctx, &previous, usecase.RetryOptions{Reasons: retryReasons})
+	if err != nil {
+		log.Fatalf("Erro ao reprocessar falhas: %v", err)
+	}
+
+	log.Println("=== Reprocessamento Concluído ===")
+	log.Printf("✓ Sucessos: %d (antes: %d)", output.Totals.Ok, len(previous.SuccessList))
+	log.Printf("✗ Falhas: %d (antes: %d)", output.Totals.Fail, len(previous.FailureList))
+	if output.Totals.NotProcessed > 0 {
+		log.Printf("⏸ Não processados: %d", output.Totals.NotProcessed)
+	}
+
+	resultJSON, err := json.MarshalIndent(output, "", "  ")
+	if err != nil {
+		log.Fatalf("Erro ao gerar JSON de resultado: %v", err)
+	}
+	if err := os.WriteFile(retryOutput, resultJSON, 0644); err != nil {
+		log.Fatalf("Erro ao salvar %s: %v", retryOutput, err)
+	}
+	log.Printf("✓ Resultado atualizado salvo em %s", retryOutput)
+
+	if retryXLSX != "" {
+		if err := file.WriteResultXLSX(retryXLSX, output); err != nil {
+			log.Fatalf("Erro ao gerar relatório Excel: %v", err)
+		}
+		log.Printf("✓ Relatório salvo em %s", retryXLSX)
+	}
+}
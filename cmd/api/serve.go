@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.thiagohmm.com.br/cargaparcial/infrastructure/http/handler"
+	"github.thiagohmm.com.br/cargaparcial/infrastructure/http/server"
+	"github.thiagohmm.com.br/cargaparcial/usecase"
+)
+
+var (
+	listenAddr      string
+	readTimeout     time.Duration
+	writeTimeout    time.Duration
+	idleTimeout     time.Duration
+	shutdownTimeout time.Duration
+	maxJobs         int
+	jobConcurrency  int
+	jobRetention    time.Duration
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Inicia o servidor HTTP da API",
+	Long: `Inicia o servidor HTTP expondo POST /api/process-products, a API de
+jobs assíncronos (/api/jobs) e as rotas de saúde (/health e /ready). Ao receber SIGINT/SIGTERM o servidor para de
+aceitar conexões e aguarda as requisições em andamento terminarem.`,
+	Run: runServe,
+}
+
+func init() {
+	serveCmd.Flags().StringVarP(&listenAddr, "addr", "a", ":8080", "Endereço de escuta do servidor HTTP")
+	serveCmd.Flags().DurationVar(&readTimeout, "read-timeout", 30*time.Second, "Tempo máximo para leitura da requisição")
+	serveCmd.Flags().DurationVar(&writeTimeout, "write-timeout", 30*time.Minute, "Tempo máximo para escrita da resposta (inclui o processamento)")
+	serveCmd.Flags().DurationVar(&idleTimeout, "idle-timeout", 2*time.Minute, "Tempo máximo de conexões keep-alive ociosas")
+	serveCmd.Flags().DurationVar(&shutdownTimeout, "shutdown-timeout", 5*time.Minute, "Tempo máximo de espera pelas requisições em andamento no desligamento")
+
+	serveCmd.Flags().IntVar(&maxJobs, "max-jobs", 100, "Número máximo de jobs assíncronos armazenados")
+	serveCmd.Flags().IntVar(&jobConcurrency, "job-concurrency", 1, "Número de jobs assíncronos executados ao mesmo tempo")
+	serveCmd.Flags().DurationVar(&jobRetention, "job-retention", 24*time.Hour, "Tempo de retenção dos jobs finalizados")
+
+	rootCmd.AddCommand(serveCmd)
+}
+
+func runServe(cmd *cobra.Command, args []string) {
+	log.Println("=== Carga Parcial - Servidor HTTP ===")
+
+	deps, err := newAppDeps()
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	defer deps.Close()
+
+	jobManager := usecase.NewJobManager(deps.processProductsUseCase, maxJobs, jobConcurrency, jobRetention)
+
+	processProductsHandler := handler.NewProcessProductsHandler(deps.processProductsUseCase)
+	jobsHandler := handler.NewJobsHandler(jobManager)
+	healthHandler := handler.NewHealthHandler(deps.db)
+
+	srv := server.NewServer(server.Config{
+		Addr:            listenAddr,
+		ReadTimeout:     readTimeout,
+		WriteTimeout:    writeTimeout,
+		IdleTimeout:     idleTimeout,
+		ShutdownTimeout: shutdownTimeout,
+	}, server.NewRouter(processProductsHandler, jobsHandler, healthHandler))
+
+	// Cancelar o contexto ao receber SIGINT/SIGTERM dispara o desligamento gracioso
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := srv.Run(ctx); err != nil {
+		deps.Close()
+		log.Fatalf("Erro no servidor HTTP: %v", err)
+	}
+
+	// Aguardar os jobs assíncronos em andamento antes de fechar banco e fila
+	jobsCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := jobManager.Shutdown(jobsCtx); err != nil {
+		log.Printf("⚠️  %v", err)
+	}
+
+	log.Println("=== Servidor Finalizado ===")
+}
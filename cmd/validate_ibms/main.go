@@ -1,25 +1,37 @@
 package main
 
 import (
-	"fmt"
+	"context"
+	"flag"
 	"log"
 
+	"github.thiagohmm.com.br/cargaparcial/domain/validation"
 	"github.thiagohmm.com.br/cargaparcial/infrastructure/config"
 	"github.thiagohmm.com.br/cargaparcial/infrastructure/database"
 	"github.thiagohmm.com.br/cargaparcial/infrastructure/file"
+	"github.thiagohmm.com.br/cargaparcial/infrastructure/repository"
 )
 
 func main() {
-	log.Println("=== Validador de IBMs do Excel ===\n")
+	excelFile := flag.String("excel", "lojas_produtos.xlsx", "Arquivo Excel com a coluna IMBLOJA")
+	ibmWidth := flag.Int("ibm-width", 0, "Tamanho canônico do IBM, ex.: 10 (0 = não completa com zeros)")
+	stripNonDigits := flag.Bool("ibm-strip-non-digits", false, "Remove do IBM os caracteres que não são dígitos")
+	maxDistance := flag.Int("max-distance", validation.DefaultSuggestDistance, "Distância máxima (dígitos diferentes) das sugestões de IBM")
+	maxSuggestions := flag.Int("suggestions", validation.DefaultSuggestLimit, "Número máximo de sugestões por IBM não encontrado")
+	flag.Parse()
 
-	// Ler arquivo Excel
-	excelFile := "lojas_produtos.xlsx"
-	data, err := file.ReadXLSX(excelFile)
+	log.Println("=== Validador de IBMs do Excel ===")
+
+	// Ler arquivo Excel (IBMs normalizados como no processamento)
+	tableOptions := file.DefaultTableOptions()
+	tableOptions.IBM = validation.IBMOptions{Width: *ibmWidth, StripNonDigits: *stripNonDigits}
+
+	data, err := file.ReadXLSXWithOptions(*excelFile, tableOptions)
 	if err != nil {
 		log.Fatalf("Erro ao ler Excel: %v", err)
 	}
 
-	log.Printf("📋 Excel lido: %d IBMs únicos, %d produtos únicos\n", len(data.IBMCodes), len(data.ProductCodes))
+	log.Printf("📋 Excel lido: %d IBMs únicos, %d produtos únicos", len(data.IBMCodes), len(data.ProductCodes))
 
 	// Conectar ao banco
 	cfg, err := config.LoadConfig(".")
@@ -43,85 +55,69 @@ func main() {
 	}
 	defer db.Close()
 
-	log.Println("✅ Conectado ao banco\n")
+	log.Println("✅ Conectado ao banco")
 
-	// Verificar cada IBM
-	found := 0
-	notFound := 0
-	notFoundList := make([]string, 0)
+	ctx := context.Background()
+	dealerRepo := repository.NewDealerRepository(db)
 
+	// Verificar os IBMs em lote
 	log.Println("🔍 Verificando IBMs do Excel no banco de dados...")
 	log.Println("------------------------------------------------------------")
 
-	for i, ibm := range data.IBMCodes {
-		var id int
-		var codigo string
+	dealers, err := dealerRepo.GetByIBMs(ctx, data.IBMCodes)
+	if err != nil {
+		log.Fatalf("Erro ao buscar revendedores: %v", err)
+	}
 
-		err := db.QueryRow("SELECT IdRevendedor, CodigoIBM FROM Revendedor WHERE CodigoIBM = :1", ibm).Scan(&id, &codigo)
+	found := 0
+	notFoundList := make([]string, 0)
 
-		if err == nil {
+	for i, ibm := range data.IBMCodes {
+		if dealer, ok := dealers[ibm]; ok {
 			found++
 			if found <= 5 {
-				log.Printf("✅ [%3d] IBM %s → ID %d (encontrado)", i+1, ibm, id)
-			}
-		} else {
-			notFound++
-			notFoundList = append(notFoundList, ibm)
-			if notFound <= 10 {
-				log.Printf("❌ [%3d] IBM %s → NÃO ENCONTRADO", i+1, ibm)
+				log.Printf("✅ [%3d] IBM %s → ID %d (encontrado)", i+1, ibm, dealer.ID)
 			}
+			continue
+		}
+
+		notFoundList = append(notFoundList, ibm)
+		if len(notFoundList) <= 10 {
+			log.Printf("❌ [%3d] IBM %s → NÃO ENCONTRADO", i+1, ibm)
 		}
 	}
+	notFound := len(notFoundList)
 
 	log.Println("------------------------------------------------------------")
-	log.Printf("\n📊 RESUMO:\n")
-	log.Printf("   Total de IBMs no Excel: %d\n", len(data.IBMCodes))
-	log.Printf("   ✅ Encontrados no banco: %d (%.1f%%)\n", found, float64(found)/float64(len(data.IBMCodes))*100)
-	log.Printf("   ❌ NÃO encontrados:      %d (%.1f%%)\n", notFound, float64(notFound)/float64(len(data.IBMCodes))*100)
-
-	if notFound > 0 {
-		log.Printf("\n⚠️  IBMs NÃO encontrados no banco:\n")
-		for i, ibm := range notFoundList {
-			if i < 20 {
-				log.Printf("   - %s\n", ibm)
-			}
-		}
-		if len(notFoundList) > 20 {
-			log.Printf("   ... e mais %d IBMs\n", len(notFoundList)-20)
-		}
+	log.Println("📊 RESUMO:")
+	log.Printf("   Total de IBMs no Excel: %d", len(data.IBMCodes))
+	log.Printf("   ✅ Encontrados no banco: %d (%.1f%%)", found, float64(found)/float64(len(data.IBMCodes))*100)
+	log.Printf("   ❌ NÃO encontrados:      %d (%.1f%%)", notFound, float64(notFound)/float64(len(data.IBMCodes))*100)
+
+	if notFound == 0 {
+		return
 	}
 
-	// Sugestão: buscar IBMs similares
-	if notFound > 0 {
-		log.Printf("\n💡 Sugestões:\n")
-		log.Println("   1. Verifique se os IBMs do Excel têm o formato correto")
-		log.Println("   2. Talvez seja necessário remover ou adicionar zeros à esquerda")
-		log.Println("   3. Verifique se os revendedores existem no banco com outro código")
-
-		// Tentar buscar IBMs similares (sem zeros à esquerda)
-		testIBM := notFoundList[0]
-		log.Printf("\n🔎 Testando variações para IBM '%s':\n", testIBM)
-
-		variations := []string{
-			testIBM,
-			fmt.Sprintf("%010s", testIBM),        // Com 10 dígitos
-			fmt.Sprintf("%d", mustAtoi(testIBM)), // Sem zeros à esquerda
-		}
+	// Sugerir IBMs cadastrados parecidos com cada IBM não encontrado
+	registered, err := dealerRepo.ListIBMs(ctx)
+	if err != nil {
+		log.Fatalf("Erro ao listar IBMs cadastrados: %v", err)
+	}
 
-		for _, v := range variations {
-			var id int
-			err := db.QueryRow("SELECT IdRevendedor FROM Revendedor WHERE CodigoIBM = :1", v).Scan(&id)
-			if err == nil {
-				log.Printf("   ✅ Variação '%s' ENCONTRADA! ID=%d\n", v, id)
-			} else {
-				log.Printf("   ❌ Variação '%s' não encontrada\n", v)
-			}
+	log.Printf("⚠️  IBMs NÃO encontrados no banco (sugestões entre %d IBMs cadastrados):", len(registered))
+	withSuggestions := 0
+	for _, ibm := range notFoundList {
+		suggestions := validation.SuggestIBMs(ibm, registered, *maxDistance, *maxSuggestions)
+		if len(suggestions) == 0 {
+			log.Printf("   - %s (sem IBMs parecidos)", ibm)
+			continue
 		}
+		withSuggestions++
+		log.Printf("   - %s → você quis dizer: %v", ibm, suggestions)
 	}
-}
 
-func mustAtoi(s string) int {
-	var n int
-	fmt.Sscanf(s, "%d", &n)
-	return n
+	log.Println("💡 Sugestões:")
+	log.Printf("   1. %d de %d IBMs não encontrados têm códigos parecidos cadastrados (possível erro de digitação)", withSuggestions, notFound)
+	log.Println("   2. Verifique se o tamanho canônico (--ibm-width) corresponde ao cadastro de Revendedor")
+	log.Println("   3. Verifique se os revendedores existem no banco com outro código")
 }
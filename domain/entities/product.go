@@ -19,6 +19,28 @@ type ProductDealer struct {
 	IsActive  bool
 }
 
+// RelationStatus indica o resultado da gravação de uma relação produto-revendedor
+type RelationStatus int
+
+const (
+	// RelationUnknown é o valor zero: o repositório não informou o resultado da relação
+	RelationUnknown RelationStatus = iota
+	// RelationCreated indica que a relação não existia e foi criada
+	RelationCreated
+	// RelationReactivated indica que a relação existia inativa e foi reativada
+	RelationReactivated
+	// RelationAlreadyActive indica que a relação já existia ativa
+	RelationAlreadyActive
+	// RelationDeactivated indica que a relação existia ativa e foi desativada
+	RelationDeactivated
+	// RelationDeleted indica que a relação existia e foi excluída
+	RelationDeleted
+	// RelationAlreadyInactive indica que a relação já existia inativa
+	RelationAlreadyInactive
+	// RelationNotFound indica que a relação a remover não existia
+	RelationNotFound
+)
+
 // ProductIntegrationStaging representa o staging de integração de produto
 type ProductIntegrationStaging struct {
 	ProductID int
@@ -1,8 +1,17 @@
 package repositories
 
-import "github.thiagohmm.com.br/cargaparcial/domain/entities"
+import (
+	"context"
+
+	"github.thiagohmm.com.br/cargaparcial/domain/entities"
+)
 
 // DealerRepository define as operações de acesso a dados para Dealer
 type DealerRepository interface {
-	GetByIBM(ibm string) (*entities.Dealer, error)
+	GetByIBM(ctx context.Context, ibm string) (*entities.Dealer, error)
+	// GetByIBMs busca os revendedores de vários IBMs de uma vez, indexados pelo IBM.
+	// IBMs sem revendedor não aparecem no mapa.
+	GetByIBMs(ctx context.Context, ibms []string) (map[string]*entities.Dealer, error)
+	// ListIBMs retorna todos os códigos IBM cadastrados (usado para sugerir IBMs parecidos)
+	ListIBMs(ctx context.Context) ([]string, error)
 }
@@ -1,10 +1,22 @@
 package repositories
 
-import "github.thiagohmm.com.br/cargaparcial/domain/entities"
+import (
+	"context"
+
+	"github.thiagohmm.com.br/cargaparcial/domain/entities"
+)
 
 // ProductDealerRepository define as operações de acesso a dados para ProductDealer
 type ProductDealerRepository interface {
-	Exists(productID, dealerID int) (bool, error)
-	Create(productDealer *entities.ProductDealer) error
-	CreateBatch(productDealers []*entities.ProductDealer) error
+	EnsureBatch(ctx context.Context, productDealers []*entities.ProductDealer) ([]entities.RelationStatus, error)
+	// PlanBatch retorna, sem gravar nada, o que EnsureBatch faria com cada relação
+	PlanBatch(ctx context.Context, productDealers []*entities.ProductDealer) ([]entities.RelationStatus, error)
+	// DeactivateBatch marca as relações como inativas e retorna se cada uma foi
+	// desativada, já estava inativa ou não existia
+	DeactivateBatch(ctx context.Context, productDealers []*entities.ProductDealer) ([]entities.RelationStatus, error)
+	// DeleteBatch exclui as relações e retorna se cada uma foi excluída ou não existia
+	DeleteBatch(ctx context.Context, productDealers []*entities.ProductDealer) ([]entities.RelationStatus, error)
+	// ListActiveProducts retorna, por IdRevendedor, os produtos com relação ativa
+	// (com um dos códigos de barras do produto, vazio quando não há embalagem)
+	ListActiveProducts(ctx context.Context, dealerIDs []int) (map[int][]entities.Product, error)
 }
@@ -1,8 +1,12 @@
 package repositories
 
-import "github.thiagohmm.com.br/cargaparcial/domain/entities"
+import (
+	"context"
+
+	"github.thiagohmm.com.br/cargaparcial/domain/entities"
+)
 
 // ProductIntegrationStagingRepository define as operações de acesso a dados para ProductIntegrationStaging
 type ProductIntegrationStagingRepository interface {
-	GetByProductAndDealer(productID, dealerID int) (*entities.ProductIntegrationStaging, error)
+	GetByProductAndDealer(ctx context.Context, productID, dealerID int) (*entities.ProductIntegrationStaging, error)
 }
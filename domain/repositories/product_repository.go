@@ -1,9 +1,16 @@
 package repositories
 
-import "github.thiagohmm.com.br/cargaparcial/domain/entities"
+import (
+	"context"
+
+	"github.thiagohmm.com.br/cargaparcial/domain/entities"
+)
 
 // ProductRepository define as operações de acesso a dados para Product
 type ProductRepository interface {
-	GetByEAN(ean string) ([]entities.Product, error)
-	SaveIntegrationStaging(dealerID, productID int) error
+	GetByEAN(ctx context.Context, ean string) ([]entities.Product, error)
+	// GetByEANs busca os produtos de vários EANs de uma vez, agrupados por EAN.
+	// EANs sem produto não aparecem no mapa.
+	GetByEANs(ctx context.Context, eans []string) (map[string][]entities.Product, error)
+	SaveIntegrationStaging(ctx context.Context, dealerID, productID int) error
 }
@@ -0,0 +1,188 @@
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Erros de validação de GTIN/EAN
+var (
+	// ErrGTINEmpty indica um código vazio
+	ErrGTINEmpty = errors.New("código vazio")
+	// ErrGTINNotNumeric indica um código com caracteres que não são dígitos
+	ErrGTINNotNumeric = errors.New("código não numérico")
+	// ErrGTINLength indica um código com mais de 14 dígitos
+	ErrGTINLength = errors.New("tamanho inválido")
+	// ErrGTINCheckDigit indica um dígito verificador que não confere
+	ErrGTINCheckDigit = errors.New("dígito verificador inválido")
+	// ErrGTINPrecisionLost indica um código em notação científica cujos dígitos foram perdidos pelo Excel
+	ErrGTINPrecisionLost = errors.New("notação científica com dígitos perdidos pelo Excel")
+)
+
+// gtinLengths são os tamanhos padrão de GTIN (GTIN-8, GTIN-12/UPC-A, GTIN-13/EAN-13 e GTIN-14)
+var gtinLengths = []int{8, 12, 13, 14}
+
+// GTINOptions configura a normalização de códigos de barras
+type GTINOptions struct {
+	// Width é o tamanho com que os códigos estão gravados em EmbalagemProduto.CODIGOBARRAS.
+	// Zero mantém o tamanho padrão do código (completando zeros à esquerda perdidos).
+	Width int
+	// SkipValidation desabilita a validação: o código é apenas aparado (para bases
+	// com códigos internos que não seguem o padrão GTIN)
+	SkipValidation bool
+}
+
+// ParseGTINWidth valida o tamanho de gravação informado na configuração
+func ParseGTINWidth(width int) (int, error) {
+	if width == 0 {
+		return 0, nil
+	}
+	for _, length := range gtinLengths {
+		if width == length {
+			return width, nil
+		}
+	}
+	return 0, fmt.Errorf("tamanho de EAN inválido: %d (use 0, 8, 12, 13 ou 14)", width)
+}
+
+// Normalize repara o código lido da planilha e confere o dígito verificador.
+// Artefatos do Excel (notação científica, sufixo ".0", apóstrofo de texto) são
+// removidos, zeros à esquerda perdidos são recompostos até o próximo tamanho padrão
+// e o resultado é ajustado a Width. O erro retornado envolve um dos ErrGTIN*.
+func (o GTINOptions) Normalize(code string) (string, error) {
+	code = strings.TrimSpace(code)
+	if o.SkipValidation {
+		return code, nil
+	}
+
+	digits, err := repairExcelNumber(code)
+	if err != nil {
+		return "", err
+	}
+
+	// Os zeros à esquerda não alteram o dígito verificador
+	trimmed := strings.TrimLeft(digits, "0")
+	if trimmed == "" {
+		return "", fmt.Errorf("%w: %q", ErrGTINCheckDigit, code)
+	}
+	if len(trimmed) > 14 {
+		return "", fmt.Errorf("%w: %q tem %d dígitos", ErrGTINLength, code, len(trimmed))
+	}
+
+	normalized := digits
+	if len(digits) > 14 {
+		normalized = digits[len(digits)-14:]
+	}
+	if o.Width > 0 {
+		normalized = padLeft(trimmed, o.Width)
+	} else {
+		normalized = padLeft(normalized, nextGTINLength(len(normalized)))
+	}
+
+	if !validCheckDigit(normalized) {
+		if isScientific(code) {
+			return "", fmt.Errorf("%w: %q", ErrGTINPrecisionLost, code)
+		}
+		return "", fmt.Errorf("%w: %q", ErrGTINCheckDigit, code)
+	}
+
+	return normalized, nil
+}
+
+// repairExcelNumber converte o código em uma sequência de dígitos, desfazendo a
+// formatação numérica do Excel quando ela não perdeu dígitos
+func repairExcelNumber(code string) (string, error) {
+	code = strings.TrimPrefix(code, "'")
+	if code == "" {
+		return "", ErrGTINEmpty
+	}
+
+	if isScientific(code) {
+		return expandScientific(code)
+	}
+
+	// "7896050201756.0" ou "7896050201756,00"
+	if integer, fraction, found := strings.Cut(strings.ReplaceAll(code, ",", "."), "."); found && strings.Trim(fraction, "0") == "" {
+		code = integer
+	}
+
+	if !isDigits(code) {
+		return "", fmt.Errorf("%w: %q", ErrGTINNotNumeric, code)
+	}
+	return code, nil
+}
+
+// isScientific indica se o código está em notação científica (ex.: 7.89605E+12)
+func isScientific(code string) bool {
+	return strings.ContainsAny(code, "eE") && strings.IndexFunc(code, func(r rune) bool {
+		return !strings.ContainsRune("0123456789.,eE+-", r)
+	}) == -1
+}
+
+// expandScientific converte a notação científica em dígitos sem passar por float,
+// preservando exatamente os dígitos da mantissa
+func expandScientific(code string) (string, error) {
+	mantissa, exponentText, _ := strings.Cut(strings.ToUpper(strings.ReplaceAll(code, ",", ".")), "E")
+	exponent, err := strconv.Atoi(exponentText)
+	if err != nil {
+		return "", fmt.Errorf("%w: %q", ErrGTINNotNumeric, code)
+	}
+
+	integer, fraction, _ := strings.Cut(mantissa, ".")
+	if !isDigits(integer) || (fraction != "" && !isDigits(fraction)) {
+		return "", fmt.Errorf("%w: %q", ErrGTINNotNumeric, code)
+	}
+
+	fraction = strings.TrimRight(fraction, "0")
+	if exponent < len(fraction) {
+		return "", fmt.Errorf("%w: %q", ErrGTINNotNumeric, code)
+	}
+
+	return integer + fraction + strings.Repeat("0", exponent-len(fraction)), nil
+}
+
+// validCheckDigit confere o dígito verificador GS1 (pesos 3 e 1 a partir da direita)
+func validCheckDigit(code string) bool {
+	sum := 0
+	for i := len(code) - 2; i >= 0; i-- {
+		digit := int(code[i] - '0')
+		if (len(code)-2-i)%2 == 0 {
+			digit *= 3
+		}
+		sum += digit
+	}
+	return (10-sum%10)%10 == int(code[len(code)-1]-'0')
+}
+
+// nextGTINLength retorna o menor tamanho padrão maior ou igual a length
+func nextGTINLength(length int) int {
+	for _, standard := range gtinLengths {
+		if length <= standard {
+			return standard
+		}
+	}
+	return length
+}
+
+// padLeft completa o código com zeros à esquerda até width
+func padLeft(code string, width int) string {
+	if len(code) >= width {
+		return code
+	}
+	return strings.Repeat("0", width-len(code)) + code
+}
+
+// isDigits indica se a string contém apenas dígitos
+func isDigits(value string) bool {
+	if value == "" {
+		return false
+	}
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
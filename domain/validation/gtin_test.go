@@ -0,0 +1,88 @@
+package validation
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestGTINOptionsNormalize(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    GTINOptions
+		code    string
+		want    string
+		wantErr error
+	}{
+		{name: "EAN-13 válido", code: "7896050201756", want: "7896050201756"},
+		{name: "espaços nas pontas", code: "  7896050201756 ", want: "7896050201756"},
+		{name: "sufixo .0 de célula numérica", code: "7896050201756.0", want: "7896050201756"},
+		{name: "sufixo ,00", code: "7896050201756,00", want: "7896050201756"},
+		{name: "apóstrofo de texto", code: "'7896050201756", want: "7896050201756"},
+		{name: "notação científica completa", code: "7.896050201756E+12", want: "7896050201756"},
+		{name: "notação científica com dígitos perdidos", code: "7.89605E+12", wantErr: ErrGTINPrecisionLost},
+		{name: "zero à esquerda perdido (UPC-A)", code: "70330717534", want: "070330717534"},
+		{name: "GTIN-8", code: "96385074", want: "96385074"},
+		{name: "GTIN-14 com zeros à esquerda", code: "07896050201756", want: "07896050201756"},
+		{name: "largura fixa 14", opts: GTINOptions{Width: 14}, code: "7896050201756", want: "07896050201756"},
+		{name: "largura fixa 13 remove zeros excedentes", opts: GTINOptions{Width: 13}, code: "07896050201756", want: "7896050201756"},
+		{name: "dígito verificador inválido", code: "7896050201757", wantErr: ErrGTINCheckDigit},
+		{name: "somente zeros", code: "0000000000000", wantErr: ErrGTINCheckDigit},
+		{name: "não numérico", code: "78960A0201756", wantErr: ErrGTINNotNumeric},
+		{name: "vazio", code: "", wantErr: ErrGTINEmpty},
+		{name: "mais de 14 dígitos", code: "178960502017561", wantErr: ErrGTINLength},
+		{name: "validação desabilitada", opts: GTINOptions{SkipValidation: true}, code: " ABC-123 ", want: "ABC-123"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.opts.Normalize(tt.code)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Normalize(%q) erro = %v, esperado %v", tt.code, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Normalize(%q) erro inesperado: %v", tt.code, err)
+			}
+			if got != tt.want {
+				t.Errorf("Normalize(%q) = %q, esperado %q", tt.code, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidCheckDigit(t *testing.T) {
+	tests := []struct {
+		code string
+		want bool
+	}{
+		{"7896050201756", true},
+		{"7896050201750", false},
+		{"070330717534", true},
+		{"070330717535", false},
+		{"96385074", true},
+		{"96385075", false},
+		{"07896050201756", true},
+		{"00000000", true},
+	}
+
+	for _, tt := range tests {
+		if got := validCheckDigit(tt.code); got != tt.want {
+			t.Errorf("validCheckDigit(%q) = %v, esperado %v", tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestParseGTINWidth(t *testing.T) {
+	for _, width := range []int{0, 8, 12, 13, 14} {
+		if got, err := ParseGTINWidth(width); err != nil || got != width {
+			t.Errorf("ParseGTINWidth(%d) = %d, %v", width, got, err)
+		}
+	}
+	for _, width := range []int{-1, 10, 15} {
+		if _, err := ParseGTINWidth(width); err == nil {
+			t.Errorf("ParseGTINWidth(%d) deveria falhar", width)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package validation
+
+import (
+	"sort"
+	"strings"
+)
+
+// Sugestões de IBMs cadastrados parecidos com um IBM sem revendedor
+const (
+	DefaultSuggestDistance = 2 // Edições máximas entre o IBM e a sugestão
+	DefaultSuggestLimit    = 3 // Sugestões por IBM
+)
+
+// IBMOptions configura a normalização dos códigos IBM da entrada
+type IBMOptions struct {
+	// Width é o tamanho canônico: códigos numéricos menores recebem zeros à esquerda
+	// (ex.: 10 para um cadastro com CodigoIBM 0001002154). Zero desabilita o preenchimento.
+	Width int
+	// StripNonDigits remove os caracteres que não são dígitos (ex.: "0001.002.154")
+	StripNonDigits bool
+}
+
+// DefaultIBMOptions retorna a normalização padrão: apenas aparar o código e desfazer
+// os artefatos do Excel. O preenchimento com zeros é opcional (Width), pois muda a
+// chave de busca de todo revendedor cujo CodigoIBM não tem o tamanho informado.
+func DefaultIBMOptions() IBMOptions {
+	return IBMOptions{}
+}
+
+// Normalize apara o código, desfaz artefatos do Excel (apóstrofo de texto, sufixo
+// ".0"), remove os não dígitos se configurado e completa códigos numéricos com
+// zeros à esquerda até Width. Códigos alfanuméricos não são preenchidos.
+func (o IBMOptions) Normalize(code string) string {
+	code = strings.TrimPrefix(strings.TrimSpace(code), "'")
+
+	// "1002154.0": IBM lido de uma célula numérica
+	if integer, fraction, found := strings.Cut(code, "."); found && isDigits(integer) && strings.Trim(fraction, "0") == "" {
+		code = integer
+	}
+
+	if o.StripNonDigits {
+		code = strings.Map(func(r rune) rune {
+			if r < '0' || r > '9' {
+				return -1
+			}
+			return r
+		}, code)
+	}
+
+	if o.Width > 0 && isDigits(code) {
+		code = padLeft(code, o.Width)
+	}
+	return code
+}
+
+// SuggestIBMs retorna até limit códigos de candidates parecidos com ibm, do mais
+// próximo ao mais distante. Os códigos são comparados sem os zeros à esquerda e
+// só entram sugestões a no máximo maxDistance edições (inserção, remoção, troca
+// ou transposição de dígitos vizinhos).
+func SuggestIBMs(ibm string, candidates []string, maxDistance, limit int) []string {
+	type suggestion struct {
+		code     string
+		distance int
+	}
+
+	target := strings.TrimLeft(ibm, "0")
+	var suggestions []suggestion
+	for _, candidate := range candidates {
+		if candidate == ibm {
+			continue
+		}
+		distance := editDistance(target, strings.TrimLeft(candidate, "0"), maxDistance)
+		if distance <= maxDistance {
+			suggestions = append(suggestions, suggestion{code: candidate, distance: distance})
+		}
+	}
+
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].distance != suggestions[j].distance {
+			return suggestions[i].distance < suggestions[j].distance
+		}
+		return suggestions[i].code < suggestions[j].code
+	})
+
+	if len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+
+	codes := make([]string, len(suggestions))
+	for i, s := range suggestions {
+		codes[i] = s.code
+	}
+	return codes
+}
+
+// editDistance calcula a distância de Damerau-Levenshtein (transposições
+// adjacentes) entre a e b, parando assim que ela excede max
+func editDistance(a, b string, max int) int {
+	if diff := len(a) - len(b); diff > max || -diff > max {
+		return max + 1
+	}
+
+	// Três linhas da matriz de programação dinâmica: i-2, i-1 e i
+	prev2 := make([]int, len(b)+1)
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		rowMin := curr[0]
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				curr[j] = min(curr[j], prev2[j-2]+1)
+			}
+			rowMin = min(rowMin, curr[j])
+		}
+		if rowMin > max {
+			return max + 1
+		}
+		prev2, prev, curr = prev, curr, prev2
+	}
+
+	return prev[len(b)]
+}
@@ -0,0 +1,98 @@
+package validation
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestIBMOptionsNormalize(t *testing.T) {
+	tests := []struct {
+		name string
+		opts IBMOptions
+		code string
+		want string
+	}{
+		{name: "padrão não completa com zeros", opts: DefaultIBMOptions(), code: "1002154", want: "1002154"},
+		{name: "padrão apara espaços", opts: DefaultIBMOptions(), code: " 0001002154 ", want: "0001002154"},
+		{name: "padrão remove apóstrofo", opts: DefaultIBMOptions(), code: "'0001002154", want: "0001002154"},
+		{name: "padrão remove sufixo .0", opts: DefaultIBMOptions(), code: "1002154.0", want: "1002154"},
+		{name: "largura 10", opts: IBMOptions{Width: 10}, code: "1002154", want: "0001002154"},
+		{name: "largura 10 com sufixo .0", opts: IBMOptions{Width: 10}, code: "1002154.00", want: "0001002154"},
+		{name: "largura 10 não trunca códigos maiores", opts: IBMOptions{Width: 10}, code: "123456789012", want: "123456789012"},
+		{name: "largura 10 não completa alfanuméricos", opts: IBMOptions{Width: 10}, code: "AB1002", want: "AB1002"},
+		{name: "formatação mantida sem StripNonDigits", opts: IBMOptions{Width: 10}, code: "0001.002.154", want: "0001.002.154"},
+		{name: "StripNonDigits", opts: IBMOptions{Width: 10, StripNonDigits: true}, code: "1.002-154", want: "0001002154"},
+		{name: "vazio", opts: IBMOptions{Width: 10}, code: "  ", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.opts.Normalize(tt.code); got != tt.want {
+				t.Errorf("Normalize(%q) = %q, esperado %q", tt.code, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		max  int
+		want int
+	}{
+		{"1002154", "1002154", 2, 0},
+		{"1002154", "1002155", 2, 1},  // troca
+		{"1002154", "100254", 2, 1},   // remoção
+		{"1002154", "10021540", 2, 1}, // inserção
+		{"1002154", "1001254", 2, 1},  // transposição de vizinhos
+		{"1002154", "1992154", 2, 2},
+		{"1002154", "9992154", 2, 3}, // acima do máximo: max+1
+		{"1002154", "1002", 2, 3},    // diferença de tamanho acima do máximo
+		{"", "12", 2, 2},
+		{"", "", 0, 0},
+	}
+
+	for _, tt := range tests {
+		if got := editDistance(tt.a, tt.b, tt.max); got != tt.want {
+			t.Errorf("editDistance(%q, %q, %d) = %d, esperado %d", tt.a, tt.b, tt.max, got, tt.want)
+		}
+	}
+}
+
+func TestSuggestIBMs(t *testing.T) {
+	registered := []string{"0001002154", "0001002155", "0001001254", "0001992154", "0009999999", "0001002156"}
+
+	tests := []struct {
+		name  string
+		ibm   string
+		max   int
+		limit int
+		want  []string
+	}{
+		{
+			name:  "ordena por distância e depois pelo código",
+			ibm:   "1002157",
+			max:   1,
+			limit: 5,
+			want:  []string{"0001002154", "0001002155", "0001002156"},
+		},
+		{
+			name:  "compara sem os zeros à esquerda e ignora o próprio código",
+			ibm:   "0001002154",
+			max:   1,
+			limit: 5,
+			want:  []string{"0001001254", "0001002155", "0001002156"},
+		},
+		{name: "respeita o limite", ibm: "1002157", max: 1, limit: 2, want: []string{"0001002154", "0001002155"}},
+		{name: "sem IBMs parecidos", ibm: "5550000", max: 2, limit: 3, want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SuggestIBMs(tt.ibm, registered, tt.max, tt.limit)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("SuggestIBMs(%q) = %v, esperado %v", tt.ibm, got, tt.want)
+			}
+		})
+	}
+}
@@ -22,6 +22,9 @@ type Conf struct {
 	ENV_REDIS_ADDR     string `mapstructure:"ENV_REDIS_ADDRESS"`
 	ENV_REDIS_PASSWORD string `mapstructure:"ENV_REDIS_PASSWORD"`
 	ENV_REDIS_EXPIRE   int    `mapstructure:"ENV_REDIS_EXPIRE"`
+	InputIBMColumns    string `mapstructure:"INPUT_IBM_COLUMNS"` // Nomes aceitos para a coluna do IBM, separados por vírgula
+	InputEANColumns    string `mapstructure:"INPUT_EAN_COLUMNS"` // Nomes aceitos para a coluna do EAN, separados por vírgula
+	UnlinkStrategy     string `mapstructure:"UNLINK_STRATEGY"`   // Remoção de relações no modo unlink: deactivate ou delete
 }
 
 type Dados struct {
@@ -76,6 +79,9 @@ func LoadConfig(path string) (*Conf, error) {
 		cfg.ENV_REDIS_ADDR = viper.GetString("ENV_REDIS_ADDRESS")
 		cfg.ENV_REDIS_PASSWORD = viper.GetString("ENV_REDIS_PASSWORD")
 		cfg.ENV_REDIS_EXPIRE = viper.GetInt("ENV_REDIS_EXPIRE")
+		cfg.InputIBMColumns = viper.GetString("INPUT_IBM_COLUMNS")
+		cfg.InputEANColumns = viper.GetString("INPUT_EAN_COLUMNS")
+		cfg.UnlinkStrategy = viper.GetString("UNLINK_STRATEGY")
 	} else {
 		err = viper.Unmarshal(&cfg)
 		if err != nil {
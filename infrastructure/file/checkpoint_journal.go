@@ -0,0 +1,195 @@
+package file
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+
+	"github.thiagohmm.com.br/cargaparcial/usecase/dto"
+)
+
+// CheckpointJournal grava o resultado de cada par processado em um arquivo
+// NDJSON (uma entrada JSON por linha), permitindo retomar a execução.
+// A primeira linha é o cabeçalho com o modo da execução que gravou o journal.
+type CheckpointJournal struct {
+	mu       sync.Mutex
+	file     *os.File
+	filename string
+	header   *dto.CheckpointHeader
+	entries  []dto.CheckpointEntry
+}
+
+// checkpointHeaderLine é a linha de cabeçalho do journal
+type checkpointHeaderLine struct {
+	Header dto.CheckpointHeader `json:"checkpoint"`
+}
+
+// checkpointLine é uma linha lida do journal: o cabeçalho ou uma entrada
+type checkpointLine struct {
+	Header *dto.CheckpointHeader `json:"checkpoint,omitempty"`
+	dto.CheckpointEntry
+}
+
+// OpenCheckpointJournal abre o journal no caminho informado.
+// Com resume=true as entradas existentes são carregadas e as novas são
+// acrescentadas ao final; caso contrário o arquivo é recriado.
+func OpenCheckpointJournal(filename string, resume bool) (*CheckpointJournal, error) {
+	journal := &CheckpointJournal{filename: filename}
+
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if resume {
+		header, entries, err := readCheckpointEntries(filename)
+		if err != nil {
+			return nil, err
+		}
+		journal.header = header
+		journal.entries = entries
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+
+	f, err := os.OpenFile(filename, flags, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao abrir journal de checkpoint: %w", err)
+	}
+	journal.file = f
+
+	// Uma última linha truncada não pode ser emendada com a próxima entrada
+	if resume && !endsWithNewline(filename) {
+		if _, err := f.Write([]byte{'\n'}); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("erro ao preparar journal de checkpoint: %w", err)
+		}
+	}
+
+	return journal, nil
+}
+
+// Header retorna o cabeçalho carregado do journal (nil em um journal novo)
+func (j *CheckpointJournal) Header() *dto.CheckpointHeader {
+	return j.header
+}
+
+// WriteHeader grava o cabeçalho na primeira linha de um journal novo
+func (j *CheckpointJournal) WriteHeader(header dto.CheckpointHeader) error {
+	if err := j.writeLine(checkpointHeaderLine{Header: header}); err != nil {
+		return err
+	}
+	j.header = &header
+	return nil
+}
+
+// Entries retorna as entradas carregadas de execuções anteriores
+func (j *CheckpointJournal) Entries() []dto.CheckpointEntry {
+	return j.entries
+}
+
+// Record acrescenta uma entrada ao journal
+func (j *CheckpointJournal) Record(entry dto.CheckpointEntry) error {
+	return j.writeLine(entry)
+}
+
+// writeLine serializa e acrescenta uma linha ao journal
+func (j *CheckpointJournal) writeLine(value any) error {
+	line, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("erro ao serializar entrada do journal: %w", err)
+	}
+	line = append(line, '\n')
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	// Uma escrita por linha: o conteúdo sobrevive a um crash do processo
+	if _, err := j.file.Write(line); err != nil {
+		return fmt.Errorf("erro ao gravar journal de checkpoint: %w", err)
+	}
+	return nil
+}
+
+// Close sincroniza e fecha o arquivo do journal
+func (j *CheckpointJournal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if err := j.file.Sync(); err != nil {
+		j.file.Close()
+		return fmt.Errorf("erro ao sincronizar journal de checkpoint: %w", err)
+	}
+	return j.file.Close()
+}
+
+// Remove apaga o arquivo do journal (após Close), quando a execução terminou
+// e não há mais o que retomar
+func (j *CheckpointJournal) Remove() error {
+	if err := os.Remove(j.filename); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("erro ao remover journal de checkpoint: %w", err)
+	}
+	return nil
+}
+
+// readCheckpointEntries lê o cabeçalho e as entradas de um journal existente.
+// Linhas inválidas (ex.: última linha truncada por um crash) são ignoradas.
+func readCheckpointEntries(filename string) (*dto.CheckpointHeader, []dto.CheckpointEntry, error) {
+	f, err := os.Open(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("erro ao abrir journal de checkpoint: %w", err)
+	}
+	defer f.Close()
+
+	var header *dto.CheckpointHeader
+	var entries []dto.CheckpointEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	lineNumber := 0
+
+	for scanner.Scan() {
+		lineNumber++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var line checkpointLine
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			log.Printf("⚠️  Linha %d do journal ignorada: %v", lineNumber, err)
+			continue
+		}
+		if line.Header != nil {
+			header = line.Header
+			continue
+		}
+		entries = append(entries, line.CheckpointEntry)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("erro ao ler linha %d do journal: %w", lineNumber, err)
+	}
+
+	return header, entries, nil
+}
+
+// endsWithNewline verifica se o arquivo está vazio ou termina com quebra de linha
+func endsWithNewline(filename string) bool {
+	f, err := os.Open(filename)
+	if err != nil {
+		return true
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil || info.Size() == 0 {
+		return true
+	}
+
+	last := make([]byte, 1)
+	if _, err := f.ReadAt(last, info.Size()-1); err != nil {
+		return true
+	}
+	return last[0] == '\n'
+}
@@ -0,0 +1,124 @@
+package file
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.thiagohmm.com.br/cargaparcial/usecase/dto"
+)
+
+func checkpointEntry(ibm, ean string) dto.CheckpointEntry {
+	return dto.CheckpointEntry{IBM: ibm, EAN: ean, Result: dto.ProductResultDTO{IBM: ibm, EAN: ean, Status: "ok"}}
+}
+
+// openJournal abre o journal e falha o teste em caso de erro
+func openJournal(t *testing.T, filename string, resume bool) *CheckpointJournal {
+	t.Helper()
+	journal, err := OpenCheckpointJournal(filename, resume)
+	if err != nil {
+		t.Fatalf("OpenCheckpointJournal(resume=%v): %v", resume, err)
+	}
+	return journal
+}
+
+func TestCheckpointJournalResume(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "carga.checkpoint")
+
+	journal := openJournal(t, filename, false)
+	if journal.Header() != nil || len(journal.Entries()) != 0 {
+		t.Fatal("journal novo deveria estar vazio")
+	}
+	header := dto.CheckpointHeader{Mode: "sync", UnlinkStrategy: "deactivate"}
+	if err := journal.WriteHeader(header); err != nil {
+		t.Fatalf("WriteHeader: %v", err)
+	}
+	for _, entry := range []dto.CheckpointEntry{checkpointEntry("1", "A"), checkpointEntry("1", "B")} {
+		if err := journal.Record(entry); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+	if err := journal.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	// Crash no meio da gravação: a última linha fica truncada
+	f, err := os.OpenFile(filename, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString(`{"IBM":"1","EAN":"C","resul`); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	journal = openJournal(t, filename, true)
+	if got := journal.Header(); got == nil || *got != header {
+		t.Errorf("Header() = %v, esperado %+v", got, header)
+	}
+	if got := journal.Entries(); len(got) != 2 || got[0].EAN != "A" || got[1].EAN != "B" || got[1].Result.Status != "ok" {
+		t.Errorf("Entries() = %+v, esperado A e B", got)
+	}
+	if err := journal.Record(checkpointEntry("1", "C")); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+	if err := journal.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	// A entrada gravada após a linha truncada não é perdida
+	journal = openJournal(t, filename, true)
+	if got := journal.Entries(); len(got) != 3 || got[2].EAN != "C" {
+		t.Errorf("Entries() = %+v, esperado A, B e C", got)
+	}
+	journal.Close()
+
+	// Sem resume o arquivo é recriado
+	journal = openJournal(t, filename, false)
+	journal.Close()
+	journal = openJournal(t, filename, true)
+	if journal.Header() != nil || len(journal.Entries()) != 0 {
+		t.Errorf("journal recriado com cabeçalho %v e %d entradas", journal.Header(), len(journal.Entries()))
+	}
+	journal.Close()
+}
+
+func TestCheckpointJournalWithoutHeader(t *testing.T) {
+	// Journal gravado antes do cabeçalho: apenas entradas
+	filename := filepath.Join(t.TempDir(), "carga.checkpoint")
+	content := `{"IBM":"1","EAN":"A","resultado":{"Status":"ok"}}` + "\n"
+	if err := os.WriteFile(filename, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	journal := openJournal(t, filename, true)
+	defer journal.Close()
+	if journal.Header() != nil {
+		t.Errorf("Header() = %+v, esperado nil", journal.Header())
+	}
+	if got := journal.Entries(); len(got) != 1 || got[0].EAN != "A" {
+		t.Errorf("Entries() = %+v, esperado A", got)
+	}
+}
+
+func TestCheckpointJournalRemove(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "carga.checkpoint")
+
+	journal := openJournal(t, filename, false)
+	if err := journal.Record(checkpointEntry("1", "A")); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+	if err := journal.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if err := journal.Remove(); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if _, err := os.Stat(filename); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("journal não foi removido: %v", err)
+	}
+	if err := journal.Remove(); err != nil {
+		t.Errorf("Remove de um journal já removido: %v", err)
+	}
+}
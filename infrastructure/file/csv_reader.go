@@ -0,0 +1,137 @@
+package file
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// csvDelimiters são os separadores reconhecidos na detecção automática
+var csvDelimiters = []rune{',', ';', '\t', '|'}
+
+// ReadCSV lê um arquivo CSV/TSV e extrai os dados das colunas IMBLOJA e CODIGOBARRAS.
+// O separador (vírgula, ponto e vírgula, tab ou pipe) é detectado pelo cabeçalho e
+// arquivos que não são UTF-8 válido são lidos como Latin-1 (exportações do Windows).
+func ReadCSV(filename string) (*XLSXData, error) {
+	return ReadCSVWithOptions(filename, DefaultTableOptions())
+}
+
+// ReadCSVWithOptions lê um arquivo CSV/TSV usando os nomes de coluna e a linha de
+// cabeçalho configurados em opts (as opções de planilha não se aplicam).
+// Pares IBM/EAN repetidos são processados uma única vez e listados em Report.DuplicatePairs.
+func ReadCSVWithOptions(filename string, opts TableOptions) (*XLSXData, error) {
+	opts = opts.withDefaults()
+
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao abrir arquivo CSV: %w", err)
+	}
+
+	content = decodeText(content)
+
+	reader := csv.NewReader(bytes.NewReader(content))
+	reader.Comma = detectDelimiter(content, opts.HeaderRow)
+	reader.FieldsPerRecord = -1 // linhas com número variável de colunas
+	reader.LazyQuotes = true
+
+	// Pular as linhas de título acima do cabeçalho
+	for i := 1; i < opts.HeaderRow; i++ {
+		if _, err := reader.Read(); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("erro ao ler linha %d: %w", i, err)
+		}
+	}
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("arquivo vazio")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler cabeçalho: %w", err)
+	}
+
+	// Encontrar índices das colunas de IBM e código de barras
+	imbLojaIdx, codigoBarrasIdx, err := opts.findColumns(header)
+	if err != nil {
+		return nil, err
+	}
+
+	collector := newTableCollector(filename, opts)
+
+	for {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler linhas: %w", err)
+		}
+
+		line, _ := reader.FieldPos(0)
+		collector.add(RowRef{Row: line}, row, imbLojaIdx, codigoBarrasIdx)
+	}
+
+	return collector.data(), nil
+}
+
+// decodeText remove o BOM UTF-8 e converte de Latin-1 para UTF-8 quando o conteúdo
+// não é UTF-8 válido
+func decodeText(content []byte) []byte {
+	content = bytes.TrimPrefix(content, []byte("\xEF\xBB\xBF"))
+	if utf8.Valid(content) {
+		return content
+	}
+
+	// Em Latin-1 cada byte corresponde ao code point de mesmo valor
+	var decoded strings.Builder
+	decoded.Grow(len(content) * 2)
+	for _, b := range content {
+		decoded.WriteRune(rune(b))
+	}
+	return []byte(decoded.String())
+}
+
+// detectDelimiter escolhe o separador mais frequente na linha de cabeçalho (a
+// headerRow-ésima linha não vazia), ignorando o conteúdo entre aspas.
+// Sem nenhum candidato, usa vírgula.
+func detectDelimiter(content []byte, headerRow int) rune {
+	var headerLine string
+	nonEmpty := 0
+	for rest := content; len(rest) > 0; {
+		var line []byte
+		line, rest, _ = bytes.Cut(rest, []byte("\n"))
+		if len(bytes.TrimSpace(line)) > 0 {
+			nonEmpty++
+			if nonEmpty >= headerRow {
+				headerLine = string(line)
+				break
+			}
+		}
+	}
+
+	counts := make(map[rune]int, len(csvDelimiters))
+	inQuotes := false
+	for _, r := range headerLine {
+		if r == '"' {
+			inQuotes = !inQuotes
+			continue
+		}
+		if !inQuotes {
+			counts[r]++
+		}
+	}
+
+	delimiter := ','
+	best := 0
+	for _, candidate := range csvDelimiters {
+		if counts[candidate] > best {
+			delimiter = candidate
+			best = counts[candidate]
+		}
+	}
+	return delimiter
+}
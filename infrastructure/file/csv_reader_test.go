@@ -0,0 +1,133 @@
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestDetectDelimiter(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		headerRow int
+		want      rune
+	}{
+		{name: "vírgula", content: "IMBLOJA,CODIGOBARRAS\n1,2\n", want: ','},
+		{name: "ponto e vírgula", content: "IMBLOJA;CODIGOBARRAS;DESCRICAO\n1;2;x\n", want: ';'},
+		{name: "tab", content: "IMBLOJA\tCODIGOBARRAS\n", want: '\t'},
+		{name: "pipe", content: "IMBLOJA|CODIGOBARRAS\n", want: '|'},
+		{name: "ignora separadores entre aspas", content: "\"LOJA;IBM\",CODIGOBARRAS\n", want: ','},
+		{name: "sem separador", content: "IMBLOJA\n", want: ','},
+		{name: "vazio", content: "", want: ','},
+		{name: "cabeçalho abaixo do título", content: "Carga de junho\n\nIMBLOJA;CODIGOBARRAS\n", headerRow: 2, want: ';'},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			headerRow := max(tt.headerRow, 1)
+			if got := detectDelimiter([]byte(tt.content), headerRow); got != tt.want {
+				t.Errorf("detectDelimiter(%q) = %q, esperado %q", tt.content, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeText(t *testing.T) {
+	tests := []struct {
+		name    string
+		content []byte
+		want    string
+	}{
+		{name: "UTF-8", content: []byte("descrição"), want: "descrição"},
+		{name: "BOM UTF-8", content: []byte("\xEF\xBB\xBFIMBLOJA"), want: "IMBLOJA"},
+		{name: "Latin-1", content: []byte("descri\xe7\xe3o"), want: "descrição"},
+	}
+
+	for _, tt := range tests {
+		if got := string(decodeText(tt.content)); got != tt.want {
+			t.Errorf("%s: decodeText = %q, esperado %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestReadCSV(t *testing.T) {
+	// Exportação do Excel no Windows: ponto e vírgula, Latin-1 e linhas em branco
+	content := "IMBLOJA;CODIGOBARRAS;DESCRI\xc7\xc3O\r\n" +
+		"0001002154;7896050201756;\"A\xe7\xfacar; 1kg\"\r\n" +
+		"\r\n" +
+		"0001002154;70330717534;Caf\xe9\r\n" +
+		"0001002155; 96385074 ;Leite\r\n" +
+		";7896050201756;sem loja\r\n"
+
+	filename := filepath.Join(t.TempDir(), "carga.csv")
+	if err := os.WriteFile(filename, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ReadCSV(filename)
+	if err != nil {
+		t.Fatalf("ReadCSV: %v", err)
+	}
+
+	want := map[string][]string{
+		"0001002154": {"7896050201756", "70330717534"},
+		"0001002155": {"96385074"},
+	}
+	if len(data.IBMToProducts) != len(want) {
+		t.Errorf("IBMToProducts = %v, esperado %v", data.IBMToProducts, want)
+	}
+	for ibm, eans := range want {
+		if !slices.Equal(data.IBMToProducts[ibm], eans) {
+			t.Errorf("IBM %s: %v, esperado %v", ibm, data.IBMToProducts[ibm], eans)
+		}
+	}
+	if len(data.IBMCodes) != 2 || len(data.ProductCodes) != 3 {
+		t.Errorf("%d IBMs e %d EANs distintos, esperado 2 e 3", len(data.IBMCodes), len(data.ProductCodes))
+	}
+}
+
+func TestReadCSVWithOptions(t *testing.T) {
+	// Linha de título acima do cabeçalho e nomes de coluna alternativos
+	content := "Sortimento das lojas\tjunho\n" +
+		"ibm\tGTIN\tEAN\n" +
+		"0001002154\t7896050201756\t0\n"
+
+	filename := filepath.Join(t.TempDir(), "carga.tsv")
+	if err := os.WriteFile(filename, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := DefaultTableOptions()
+	opts.HeaderRow = 2
+	data, err := ReadCSVWithOptions(filename, opts)
+	if err != nil {
+		t.Fatalf("ReadCSVWithOptions: %v", err)
+	}
+
+	// EAN tem prioridade sobre GTIN entre os nomes padrão
+	if got := data.IBMToProducts["0001002154"]; !slices.Equal(got, []string{"0"}) {
+		t.Errorf("IBM 0001002154: %v, esperado [0]", got)
+	}
+
+	opts.EANColumns = []string{"GTIN"}
+	data, err = ReadCSVWithOptions(filename, opts)
+	if err != nil {
+		t.Fatalf("ReadCSVWithOptions: %v", err)
+	}
+	if got := data.IBMToProducts["0001002154"]; !slices.Equal(got, []string{"7896050201756"}) {
+		t.Errorf("IBM 0001002154 com a coluna GTIN: %v, esperado [7896050201756]", got)
+	}
+}
+
+func TestReadCSVMissingColumn(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "carga.csv")
+	if err := os.WriteFile(filename, []byte("LOJA,EAN\n1,2\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ReadCSV(filename); err == nil {
+		t.Error("arquivo sem a coluna IMBLOJA deveria falhar")
+	}
+}
@@ -0,0 +1,87 @@
+package file
+
+import (
+	"fmt"
+
+	"github.com/xuri/excelize/v2"
+	"github.thiagohmm.com.br/cargaparcial/usecase/dto"
+)
+
+// Situações de um EAN na planilha de detalhes do diff
+const (
+	diffLinked    = "Já vinculado"
+	diffToLink    = "A vincular"
+	diffUnknown   = "Desconhecido"
+	diffAmbiguous = "Ambíguo"
+	diffAbsent    = "Vinculado, ausente no arquivo"
+)
+
+// WriteDiffXLSX grava o diff em um arquivo Excel com as planilhas "Resumo"
+// (totais por loja) e "Detalhes" (uma linha por IBM/EAN com sua situação)
+func WriteDiffXLSX(filename string, diff *dto.DiffOutput) error {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	const summarySheet, detailSheet = "Resumo", "Detalhes"
+	if err := f.SetSheetName("Sheet1", summarySheet); err != nil {
+		return fmt.Errorf("erro ao criar planilha %s: %w", summarySheet, err)
+	}
+	if _, err := f.NewSheet(detailSheet); err != nil {
+		return fmt.Errorf("erro ao criar planilha %s: %w", detailSheet, err)
+	}
+
+	summary := [][]interface{}{{"IBM", "IdRevendedor", "Já vinculados", "A vincular", "Desconhecidos", "Ambíguos", "Ausentes no arquivo"}}
+	details := [][]interface{}{{"IBM", "EAN", "IdProduto", "Situação"}}
+
+	for _, store := range diff.Stores {
+		summary = append(summary, []interface{}{store.IBM, store.DealerID, len(store.Linked), len(store.ToLink), len(store.Unknown), len(store.Ambiguous), len(store.Absent)})
+
+		for _, group := range []struct {
+			eans      []string
+			situation string
+		}{
+			{store.Linked, diffLinked},
+			{store.ToLink, diffToLink},
+			{store.Unknown, diffUnknown},
+			{store.Ambiguous, diffAmbiguous},
+		} {
+			for _, ean := range group.eans {
+				details = append(details, []interface{}{store.IBM, ean, nil, group.situation})
+			}
+		}
+		for _, product := range store.Absent {
+			details = append(details, []interface{}{store.IBM, product.EAN, product.ProductID, diffAbsent})
+		}
+	}
+
+	for _, ibm := range diff.UnknownStores {
+		summary = append(summary, []interface{}{ibm, "Revendedor não encontrado"})
+	}
+
+	if err := writeRows(f, summarySheet, summary); err != nil {
+		return err
+	}
+	if err := writeRows(f, detailSheet, details); err != nil {
+		return err
+	}
+
+	if err := f.SaveAs(filename); err != nil {
+		return fmt.Errorf("erro ao salvar %s: %w", filename, err)
+	}
+	return nil
+}
+
+// writeRows grava as linhas a partir da célula A1 da planilha, com o cabeçalho congelado
+func writeRows(f *excelize.File, sheet string, rows [][]interface{}) error {
+	for i, row := range rows {
+		cell, err := excelize.CoordinatesToCellName(1, i+1)
+		if err != nil {
+			return err
+		}
+		if err := f.SetSheetRow(sheet, cell, &row); err != nil {
+			return fmt.Errorf("erro ao escrever planilha %s: %w", sheet, err)
+		}
+	}
+
+	return f.SetPanes(sheet, &excelize.Panes{Freeze: true, YSplit: 1, TopLeftCell: "A2", ActivePane: "bottomLeft"})
+}
@@ -0,0 +1,231 @@
+package file
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.thiagohmm.com.br/cargaparcial/domain/validation"
+)
+
+// Motivos de linhas ignoradas na leitura
+const (
+	skipEmptyRow = "linha vazia"
+	skipNoIBM    = "IBM vazio"
+	skipNoEAN    = "código de barras vazio"
+)
+
+// InputReport é a análise prévia (pre-flight) de um arquivo de entrada: linhas lidas,
+// linhas ignoradas, pares repetidos, contagem por loja e total de jobs após a deduplicação
+type InputReport struct {
+	File           string          `json:"arquivo"`
+	Sheets         []string        `json:"planilhas,omitempty"`
+	Rows           int             `json:"linhas"`
+	ValidRows      int             `json:"linhasValidas"`
+	SkippedRows    []SkippedRow    `json:"linhasIgnoradas"`
+	DuplicatePairs []DuplicatePair `json:"paresDuplicados"`
+	Stores         []StoreSummary  `json:"lojas"`
+	EmptyStores    []string        `json:"lojasSemProdutos"` // IBMs que só aparecem em linhas ignoradas
+	IBMs           int             `json:"ibmsUnicos"`
+	EANs           int             `json:"eansUnicos"`
+	Jobs           int             `json:"jobs"` // Pares IBM/EAN distintos a processar
+
+	// IBMs sem revendedor cadastrado, com sugestões de IBMs parecidos
+	// (somente quando a análise consulta o banco: inspect --check-ibms)
+	UnknownIBMs []UnknownIBM `json:"ibmsNaoEncontrados,omitempty"`
+}
+
+// UnknownIBM é um IBM da entrada sem revendedor cadastrado
+type UnknownIBM struct {
+	IBM         string   `json:"ibm"`
+	Suggestions []string `json:"sugestoes,omitempty"` // IBMs cadastrados parecidos (possível erro de digitação)
+}
+
+// RowRef identifica uma linha do arquivo (a planilha é vazia em CSV/TSV)
+type RowRef struct {
+	Sheet string `json:"planilha,omitempty"`
+	Row   int    `json:"linha"`
+}
+
+// String formata a referência como "Planilha!linha" (ou "linha N" em CSV)
+func (r RowRef) String() string {
+	if r.Sheet == "" {
+		return fmt.Sprintf("linha %d", r.Row)
+	}
+	return fmt.Sprintf("%s!%d", r.Sheet, r.Row)
+}
+
+// PairRows associa cada par IBM/EAN (IBM normalizado, EAN como lido) às linhas
+// do arquivo em que aparece
+type PairRows map[[2]string][]RowRef
+
+// Lookup retorna as linhas do par IBM/EAN (nil quando o par não veio do arquivo)
+func (p PairRows) Lookup(ibm, ean string) []RowRef {
+	return p[[2]string{ibm, ean}]
+}
+
+// Labels converte as linhas de cada par em texto (ex.: "Plan1!12"), no formato
+// de dto.ProcessProductsInput.SourceRows
+func (p PairRows) Labels() map[[2]string][]string {
+	if p == nil {
+		return nil
+	}
+	labels := make(map[[2]string][]string, len(p))
+	for key, refs := range p {
+		parts := make([]string, len(refs))
+		for i, ref := range refs {
+			parts[i] = ref.String()
+		}
+		labels[key] = parts
+	}
+	return labels
+}
+
+// SkippedRow é uma linha ignorada e o motivo
+type SkippedRow struct {
+	RowRef
+	Reason string `json:"motivo"`
+}
+
+// DuplicatePair é um par IBM/EAN que aparece em mais de uma linha
+type DuplicatePair struct {
+	IBM  string   `json:"IBM"`
+	EAN  string   `json:"EAN"`
+	Rows []RowRef `json:"linhas"`
+}
+
+// StoreSummary resume as linhas de uma loja
+type StoreSummary struct {
+	IBM      string `json:"IBM"`
+	Rows     int    `json:"linhas"`
+	Products int    `json:"produtos"` // EANs distintos
+}
+
+// DuplicateRows retorna o número de linhas repetidas (além da primeira ocorrência de cada par)
+func (r *InputReport) DuplicateRows() int {
+	total := 0
+	for _, pair := range r.DuplicatePairs {
+		total += len(pair.Rows) - 1
+	}
+	return total
+}
+
+// tableCollector acumula as linhas lidas de um arquivo tabular e monta o InputReport
+type tableCollector struct {
+	ibmOptions validation.IBMOptions
+	report     InputReport
+
+	ibmToProducts map[string][]string // todas as linhas válidas, mantendo duplicatas
+	unique        map[string][]string // pares distintos
+	rows          PairRows
+	firstRow      map[[2]string]RowRef
+	duplicates    map[[2]string]int // índice em report.DuplicatePairs
+	storeRows     map[string]int
+	skippedIBMs   map[string]bool
+}
+
+// newTableCollector cria o coletor de um arquivo
+func newTableCollector(filename string, opts TableOptions) *tableCollector {
+	return &tableCollector{
+		ibmOptions:    opts.IBM,
+		report:        InputReport{File: filename, SkippedRows: []SkippedRow{}, DuplicatePairs: []DuplicatePair{}},
+		ibmToProducts: make(map[string][]string),
+		unique:        make(map[string][]string),
+		rows:          make(PairRows),
+		firstRow:      make(map[[2]string]RowRef),
+		duplicates:    make(map[[2]string]int),
+		storeRows:     make(map[string]int),
+		skippedIBMs:   make(map[string]bool),
+	}
+}
+
+// addSheet registra uma planilha lida
+func (c *tableCollector) addSheet(sheet string) {
+	c.report.Sheets = append(c.report.Sheets, sheet)
+}
+
+// add registra uma linha de dados. Linhas sem IBM ou sem código de barras são
+// ignoradas com o motivo; o IBM é normalizado para que "1002154" e "0001002154"
+// caiam na mesma loja.
+func (c *tableCollector) add(ref RowRef, row []string, imbLojaIdx, codigoBarrasIdx int) {
+	c.report.Rows++
+
+	ibmCode := c.ibmOptions.Normalize(cell(row, imbLojaIdx))
+	productCode := strings.TrimSpace(cell(row, codigoBarrasIdx))
+
+	switch {
+	case ibmCode == "" && productCode == "":
+		c.skip(ref, skipEmptyRow)
+		return
+	case ibmCode == "":
+		c.skip(ref, skipNoIBM)
+		return
+	case productCode == "":
+		c.skippedIBMs[ibmCode] = true
+		c.skip(ref, skipNoEAN)
+		return
+	}
+
+	c.report.ValidRows++
+	c.storeRows[ibmCode]++
+	c.ibmToProducts[ibmCode] = append(c.ibmToProducts[ibmCode], productCode)
+
+	key := [2]string{ibmCode, productCode}
+	c.rows[key] = append(c.rows[key], ref)
+
+	first, seen := c.firstRow[key]
+	if !seen {
+		c.firstRow[key] = ref
+		c.unique[ibmCode] = append(c.unique[ibmCode], productCode)
+		return
+	}
+
+	idx, tracked := c.duplicates[key]
+	if !tracked {
+		idx = len(c.report.DuplicatePairs)
+		c.duplicates[key] = idx
+		c.report.DuplicatePairs = append(c.report.DuplicatePairs, DuplicatePair{IBM: ibmCode, EAN: productCode, Rows: []RowRef{first}})
+	}
+	c.report.DuplicatePairs[idx].Rows = append(c.report.DuplicatePairs[idx].Rows, ref)
+}
+
+// skip registra uma linha ignorada
+func (c *tableCollector) skip(ref RowRef, reason string) {
+	c.report.SkippedRows = append(c.report.SkippedRows, SkippedRow{RowRef: ref, Reason: reason})
+}
+
+// data monta os dados deduplicados com o relatório da leitura
+func (c *tableCollector) data() *XLSXData {
+	data := newXLSXData(c.unique)
+
+	report := c.report
+	report.IBMs = len(data.IBMCodes)
+	report.EANs = len(data.ProductCodes)
+
+	report.Stores = make([]StoreSummary, 0, len(c.unique))
+	for ibm, products := range c.unique {
+		report.Stores = append(report.Stores, StoreSummary{IBM: ibm, Rows: c.storeRows[ibm], Products: len(products)})
+		report.Jobs += len(products)
+	}
+	sort.Slice(report.Stores, func(i, j int) bool { return report.Stores[i].IBM < report.Stores[j].IBM })
+
+	report.EmptyStores = []string{}
+	for ibm := range c.skippedIBMs {
+		if _, hasProducts := c.unique[ibm]; !hasProducts {
+			report.EmptyStores = append(report.EmptyStores, ibm)
+		}
+	}
+	sort.Strings(report.EmptyStores)
+
+	data.Report = &report
+	data.Rows = c.rows
+	return data
+}
+
+// cell retorna o valor da coluna ou vazio quando a linha é curta
+func cell(row []string, idx int) string {
+	if idx >= len(row) {
+		return ""
+	}
+	return row[idx]
+}
@@ -0,0 +1,313 @@
+package file
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+	"github.thiagohmm.com.br/cargaparcial/usecase/dto"
+)
+
+// rowWriter grava a próxima linha de uma planilha
+type rowWriter func(row []interface{}) error
+
+// reportSheet é uma planilha do relatório: write grava suas linhas (a primeira é o cabeçalho)
+type reportSheet struct {
+	name  string
+	write func(add rowWriter) error
+}
+
+// WriteResultXLSX grava o resultado de uma execução em um arquivo Excel com as planilhas
+// "Lojas" (resumo por IBM), "Sucessos", "Falhas" (ordenadas por motivo), "Motivos"
+// (falhas por código de motivo) e, se houver, "Não processados". As linhas de origem
+// vêm de LinhasOrigem de cada resultado (vazias, por exemplo, em streaming). As planilhas
+// são gravadas com o StreamWriter do excelize, sem montar todas as linhas em memória.
+func WriteResultXLSX(filename string, output *dto.ProcessProductsOutput) error {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	sheets := []reportSheet{
+		{"Lojas", tableRows(storeSummaryRows(output))},
+		{"Sucessos", func(add rowWriter) error {
+			return writeResultRows(add, output.SuccessList, nil, false)
+		}},
+		{"Falhas", func(add rowWriter) error {
+			return writeResultRows(add, output.FailureList, orderByReason(output.FailureList), true)
+		}},
+		{"Motivos", tableRows(reasonRows(output.FailureList))},
+	}
+	if len(output.NotProcessedList) > 0 {
+		sheets = append(sheets, reportSheet{"Não processados", func(add rowWriter) error {
+			return writeResultRows(add, output.NotProcessedList, nil, true)
+		}})
+	}
+
+	for i, sheet := range sheets {
+		if i == 0 {
+			if err := f.SetSheetName("Sheet1", sheet.name); err != nil {
+				return fmt.Errorf("erro ao criar planilha %s: %w", sheet.name, err)
+			}
+		} else if _, err := f.NewSheet(sheet.name); err != nil {
+			return fmt.Errorf("erro ao criar planilha %s: %w", sheet.name, err)
+		}
+
+		if err := streamSheet(f, sheet); err != nil {
+			return err
+		}
+	}
+
+	if err := f.SaveAs(filename); err != nil {
+		return fmt.Errorf("erro ao salvar %s: %w", filename, err)
+	}
+	return nil
+}
+
+// streamSheet grava as linhas da planilha com um StreamWriter, com o cabeçalho congelado
+func streamSheet(f *excelize.File, sheet reportSheet) error {
+	sw, err := f.NewStreamWriter(sheet.name)
+	if err != nil {
+		return fmt.Errorf("erro ao escrever planilha %s: %w", sheet.name, err)
+	}
+	// SetPanes precisa ser chamado antes da primeira linha
+	if err := sw.SetPanes(&excelize.Panes{Freeze: true, YSplit: 1, TopLeftCell: "A2", ActivePane: "bottomLeft"}); err != nil {
+		return fmt.Errorf("erro ao escrever planilha %s: %w", sheet.name, err)
+	}
+
+	rowNum := 0
+	add := func(row []interface{}) error {
+		rowNum++
+		cell, err := excelize.CoordinatesToCellName(1, rowNum)
+		if err != nil {
+			return err
+		}
+		if err := sw.SetRow(cell, row); err != nil {
+			return fmt.Errorf("erro ao escrever planilha %s: %w", sheet.name, err)
+		}
+		return nil
+	}
+	if err := sheet.write(add); err != nil {
+		return err
+	}
+
+	if err := sw.Flush(); err != nil {
+		return fmt.Errorf("erro ao escrever planilha %s: %w", sheet.name, err)
+	}
+	return nil
+}
+
+// tableRows grava linhas já montadas (tabelas pequenas, como os resumos por loja e motivo)
+func tableRows(rows [][]interface{}) func(add rowWriter) error {
+	return func(add rowWriter) error {
+		for _, row := range rows {
+			if err := add(row); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// storeSummaryRows monta o resumo por loja: pares solicitados (os da entrada, sem as
+// remoções planejadas pela sincronização), concluídos com sucesso, com falha e não processados. Os sucessos são rotulados pelo modo da execução
+// (vinculados, desvinculados ou, na sincronização, vinculados e removidos) e, em
+// simulação, como ações planejadas.
+func storeSummaryRows(output *dto.ProcessProductsOutput) [][]interface{} {
+	type storeTotals struct {
+		dealerID                                         *int
+		requested, linked, removed, failed, notProcessed int
+	}
+
+	stores := make(map[string]*storeTotals)
+	count := func(results []dto.ProductResultDTO, inc func(*storeTotals, dto.ProductResultDTO)) {
+		for _, result := range results {
+			totals, ok := stores[result.IBM]
+			if !ok {
+				totals = &storeTotals{}
+				stores[result.IBM] = totals
+			}
+			if totals.dealerID == nil {
+				totals.dealerID = result.DealerID
+			}
+			// As remoções da sincronização não vieram da entrada
+			if output.Mode != dto.ModeSync || !isRemovalResult(output.Mode, result) {
+				totals.requested++
+			}
+			inc(totals, result)
+		}
+	}
+	count(output.SuccessList, func(t *storeTotals, result dto.ProductResultDTO) {
+		if isRemovalResult(output.Mode, result) {
+			t.removed++
+			return
+		}
+		t.linked++
+	})
+	count(output.FailureList, func(t *storeTotals, _ dto.ProductResultDTO) { t.failed++ })
+	count(output.NotProcessedList, func(t *storeTotals, _ dto.ProductResultDTO) { t.notProcessed++ })
+
+	ibms := make([]string, 0, len(stores))
+	for ibm := range stores {
+		ibms = append(ibms, ibm)
+	}
+	sort.Strings(ibms)
+
+	linkedLabel, removedLabel := "Vinculados", "Desvinculados"
+	if output.DryRun {
+		linkedLabel, removedLabel = "A vincular", "A desvincular"
+	}
+	if output.Mode == dto.ModeSync {
+		removedLabel = "Removidos"
+		if output.DryRun {
+			removedLabel = "A remover"
+		}
+	}
+
+	header := []interface{}{"IBM", "IdRevendedor", "Solicitados"}
+	switch output.Mode {
+	case dto.ModeUnlink:
+		header = append(header, removedLabel)
+	case dto.ModeSync:
+		header = append(header, linkedLabel, removedLabel)
+	default:
+		header = append(header, linkedLabel)
+	}
+	header = append(header, "Falhas", "Não processados")
+
+	table := [][]interface{}{header}
+	for _, ibm := range ibms {
+		totals := stores[ibm]
+		row := []interface{}{ibm, intOrNil(totals.dealerID), totals.requested}
+		switch output.Mode {
+		case dto.ModeUnlink:
+			row = append(row, totals.removed)
+		case dto.ModeSync:
+			row = append(row, totals.linked, totals.removed)
+		default:
+			row = append(row, totals.linked)
+		}
+		table = append(table, append(row, totals.failed, totals.notProcessed))
+	}
+	return table
+}
+
+// isRemovalResult indica se o resultado é de um par a desvincular: todos no modo unlink e,
+// na sincronização, os pares com relação desativada, excluída ou já inativa e as falhas
+// ao remover a relação
+func isRemovalResult(mode string, result dto.ProductResultDTO) bool {
+	switch mode {
+	case dto.ModeUnlink:
+		return true
+	case dto.ModeSync:
+		switch result.Relation {
+		case dto.RelationDeactivated, dto.RelationDeleted, dto.RelationAlreadyInactive:
+			return true
+		}
+		return result.ReasonCode == dto.ReasonRelationRemoveError
+	}
+	return false
+}
+
+// writeResultRows grava uma linha por resultado, com as linhas de origem na entrada, na
+// ordem dos índices de order (nil mantém a ordem da lista)
+func writeResultRows(add rowWriter, results []dto.ProductResultDTO, order []int, withReason bool) error {
+	header := []interface{}{"IBM", "EAN", "IdRevendedor", "IdProduto", "Relação", "Linhas de origem"}
+	if withReason {
+		header = append([]interface{}{"Código", "Motivo"}, header...)
+		header = append(header, "Sugestões de IBM", "Código do erro", "Erro do banco")
+	}
+	if err := add(header); err != nil {
+		return err
+	}
+
+	for i := range results {
+		index := i
+		if order != nil {
+			index = order[i]
+		}
+		result := &results[index]
+		row := []interface{}{result.IBM, result.EAN, intOrNil(result.DealerID), intOrNil(result.ProductID), result.Relation, strings.Join(result.SourceRows, ", ")}
+		if withReason {
+			row = append([]interface{}{result.ReasonCode, result.Reason}, row...)
+			row = append(row, strings.Join(result.IBMSuggestions, ", "), result.DBCode, result.DBError)
+		}
+		if err := add(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reasonRows conta as falhas por código de motivo, da mais frequente para a menos
+// frequente. Resultados sem código (versão 1) são agrupados pelo texto do motivo.
+func reasonRows(failures []dto.ProductResultDTO) [][]interface{} {
+	type reasonGroup struct {
+		code, reason string
+		count        int
+	}
+
+	groups := make(map[string]*reasonGroup)
+	for _, result := range failures {
+		key := result.ReasonCode
+		if key == "" {
+			key = result.Reason
+		}
+		group, ok := groups[key]
+		if !ok {
+			group = &reasonGroup{code: result.ReasonCode, reason: result.Reason}
+			groups[key] = group
+		}
+		group.count++
+	}
+
+	sorted := make([]*reasonGroup, 0, len(groups))
+	for _, group := range groups {
+		sorted = append(sorted, group)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].count != sorted[j].count {
+			return sorted[i].count > sorted[j].count
+		}
+		if sorted[i].code != sorted[j].code {
+			return sorted[i].code < sorted[j].code
+		}
+		return sorted[i].reason < sorted[j].reason
+	})
+
+	table := [][]interface{}{{"Código", "Motivo", "Quantidade"}}
+	for _, group := range sorted {
+		table = append(table, []interface{}{group.code, group.reason, group.count})
+	}
+	return table
+}
+
+// orderByReason retorna os índices das falhas agrupadas por motivo, depois por IBM e EAN,
+// sem copiar a lista
+func orderByReason(failures []dto.ProductResultDTO) []int {
+	order := make([]int, len(failures))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := &failures[order[i]], &failures[order[j]]
+		if a.ReasonCode != b.ReasonCode {
+			return a.ReasonCode < b.ReasonCode
+		}
+		if a.Reason != b.Reason {
+			return a.Reason < b.Reason
+		}
+		if a.IBM != b.IBM {
+			return a.IBM < b.IBM
+		}
+		return a.EAN < b.EAN
+	})
+	return order
+}
+
+// intOrNil converte um ID opcional em valor de célula (vazia quando nil)
+func intOrNil(value *int) interface{} {
+	if value == nil {
+		return nil
+	}
+	return *value
+}
@@ -0,0 +1,84 @@
+package file
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+	"github.thiagohmm.com.br/cargaparcial/usecase/dto"
+)
+
+func TestWriteResultXLSX(t *testing.T) {
+	dealerID := 1
+	output := &dto.ProcessProductsOutput{
+		Mode: dto.ModeSync,
+		SuccessList: []dto.ProductResultDTO{
+			{IBM: "1", EAN: "7896050201756", DealerID: &dealerID, Relation: dto.RelationCreated},
+			{IBM: "1", EAN: "70330717534", DealerID: &dealerID, Relation: dto.RelationDeactivated},
+		},
+		FailureList: []dto.ProductResultDTO{
+			{IBM: "1", EAN: "96385074", ReasonCode: dto.ReasonRelationRemoveError, Reason: "remoção"},
+			{IBM: "1", EAN: "124", ReasonCode: dto.ReasonInvalidEAN, Reason: "EAN inválido"},
+			{IBM: "2", EAN: "7896050201756", ReasonCode: dto.ReasonDealerNotFound, Reason: "loja"},
+		},
+	}
+
+	filename := filepath.Join(t.TempDir(), "resultado.xlsx")
+	if err := WriteResultXLSX(filename, output); err != nil {
+		t.Fatalf("WriteResultXLSX: %v", err)
+	}
+
+	f, err := excelize.OpenFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if got, want := f.GetSheetList(), []string{"Lojas", "Sucessos", "Falhas", "Motivos"}; !slices.Equal(got, want) {
+		t.Errorf("planilhas = %v, esperado %v", got, want)
+	}
+
+	rows, err := f.GetRows("Lojas")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// As remoções da sincronização, com sucesso ou falha, não contam como solicitadas
+	want := [][]string{
+		{"IBM", "IdRevendedor", "Solicitados", "Vinculados", "Removidos", "Falhas", "Não processados"},
+		{"1", "1", "2", "1", "1", "2", "0"},
+		{"2", "", "1", "0", "0", "1", "0"},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("Lojas = %v, esperado %v", rows, want)
+	}
+	for i := range want {
+		if !slices.Equal(rows[i], want[i]) {
+			t.Errorf("Lojas linha %d = %v, esperado %v", i+1, rows[i], want[i])
+		}
+	}
+
+	rows, err = f.GetRows("Falhas")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 4 {
+		t.Fatalf("Falhas com %d linhas, esperado 4", len(rows))
+	}
+	var codes []string
+	for _, row := range rows[1:] {
+		codes = append(codes, row[0])
+	}
+	if want := []string{dto.ReasonDealerNotFound, dto.ReasonInvalidEAN, dto.ReasonRelationRemoveError}; !slices.Equal(codes, want) {
+		t.Errorf("Falhas ordenadas por %v, esperado %v", codes, want)
+	}
+
+	if rows, err := f.GetRows("Sucessos"); err != nil || len(rows) != 3 {
+		t.Errorf("Sucessos com %d linhas (%v), esperado 3", len(rows), err)
+	}
+
+	panes, err := f.GetPanes("Falhas")
+	if err != nil || !panes.Freeze || panes.YSplit != 1 {
+		t.Errorf("Falhas sem o cabeçalho congelado: %+v (%v)", panes, err)
+	}
+}
@@ -0,0 +1,148 @@
+package file
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.thiagohmm.com.br/cargaparcial/usecase/dto"
+)
+
+// NDJSONResultSink grava cada resultado em uma linha JSON assim que ele é produzido
+type NDJSONResultSink struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewNDJSONResultSink cria (ou recria) o arquivo NDJSON de resultados
+func NewNDJSONResultSink(filename string) (*NDJSONResultSink, error) {
+	f, err := os.Create(filename)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criar arquivo de resultados %s: %w", filename, err)
+	}
+	return &NDJSONResultSink{file: f}, nil
+}
+
+// Write acrescenta o resultado ao arquivo
+func (s *NDJSONResultSink) Write(result dto.ProductResultDTO) error {
+	line, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("erro ao serializar resultado: %w", err)
+	}
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Uma escrita por linha: o conteúdo sobrevive a um crash do processo
+	if _, err := s.file.Write(line); err != nil {
+		return fmt.Errorf("erro ao gravar resultado: %w", err)
+	}
+	return nil
+}
+
+// Close sincroniza e fecha o arquivo
+func (s *NDJSONResultSink) Close() error {
+	return closeSynced(s.file)
+}
+
+// csvResultHeader é o cabeçalho do arquivo CSV de resultados
+var csvResultHeader = []string{"Status", "CodigoMotivo", "Motivo", "IBM", "EAN", "IdRevendedor", "IdProduto", "Relacao", "ProdutosCandidatos", "SugestoesIBM", "AcoesPlanejadas", "LinhasOrigem", "CodigoErroBanco", "ErroBanco"}
+
+// CSVResultSink grava cada resultado em uma linha CSV assim que ele é produzido
+type CSVResultSink struct {
+	mu     sync.Mutex
+	file   *os.File
+	writer *csv.Writer
+}
+
+// NewCSVResultSink cria (ou recria) o arquivo CSV de resultados, separado por ";"
+// como os arquivos exportados do ERP
+func NewCSVResultSink(filename string) (*CSVResultSink, error) {
+	f, err := os.Create(filename)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao criar arquivo de resultados %s: %w", filename, err)
+	}
+
+	writer := csv.NewWriter(f)
+	writer.Comma = ';'
+	if err := writer.Write(csvResultHeader); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("erro ao gravar cabeçalho de %s: %w", filename, err)
+	}
+	writer.Flush()
+
+	return &CSVResultSink{file: f, writer: writer}, nil
+}
+
+// Write acrescenta o resultado ao arquivo
+func (s *CSVResultSink) Write(result dto.ProductResultDTO) error {
+	record := []string{
+		result.Status,
+		result.ReasonCode,
+		result.Reason,
+		result.IBM,
+		result.EAN,
+		optionalInt(result.DealerID),
+		optionalInt(result.ProductID),
+		result.Relation,
+		joinInts(result.Candidates),
+		strings.Join(result.IBMSuggestions, ","),
+		strings.Join(result.Planned, ","),
+		strings.Join(result.SourceRows, ","),
+		result.DBCode,
+		result.DBError,
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.writer.Write(record); err != nil {
+		return fmt.Errorf("erro ao gravar resultado: %w", err)
+	}
+	// Flush por linha: o conteúdo sobrevive a um crash do processo
+	s.writer.Flush()
+	if err := s.writer.Error(); err != nil {
+		return fmt.Errorf("erro ao gravar resultado: %w", err)
+	}
+	return nil
+}
+
+// Close sincroniza e fecha o arquivo
+func (s *CSVResultSink) Close() error {
+	s.writer.Flush()
+	return closeSynced(s.file)
+}
+
+// closeSynced sincroniza o arquivo com o disco antes de fechá-lo
+func closeSynced(f *os.File) error {
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("erro ao sincronizar %s: %w", f.Name(), err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("erro ao fechar %s: %w", f.Name(), err)
+	}
+	return nil
+}
+
+// optionalInt formata um ID opcional (vazio quando nil)
+func optionalInt(value *int) string {
+	if value == nil {
+		return ""
+	}
+	return strconv.Itoa(*value)
+}
+
+// joinInts junta os números separados por vírgula
+func joinInts(values []int) string {
+	parts := make([]string, len(values))
+	for i, value := range values {
+		parts[i] = strconv.Itoa(value)
+	}
+	return strings.Join(parts, ",")
+}
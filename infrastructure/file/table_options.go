@@ -0,0 +1,130 @@
+package file
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.thiagohmm.com.br/cargaparcial/domain/validation"
+)
+
+// Nomes de coluna aceitos por padrão, em ordem de prioridade
+var (
+	DefaultIBMColumns = []string{"IMBLOJA", "IBM", "COD_LOJA"}
+	DefaultEANColumns = []string{"CODIGOBARRAS", "EAN", "GTIN"}
+)
+
+// TableOptions configura a leitura de arquivos tabulares (XLSX e CSV/TSV)
+type TableOptions struct {
+	// IBMColumns são os nomes aceitos para a coluna do código IBM, em ordem de prioridade
+	IBMColumns []string
+	// EANColumns são os nomes aceitos para a coluna do código de barras, em ordem de prioridade
+	EANColumns []string
+	// Sheet seleciona a planilha pelo nome ou pelo índice (1 = primeira). Vazio usa a primeira.
+	Sheet string
+	// AllSheets lê todas as planilhas que possuem as colunas (ignora Sheet)
+	AllSheets bool
+	// HeaderRow é a linha do cabeçalho (1 = primeira), para arquivos com linhas de título acima dele
+	HeaderRow int
+	// IBM normaliza os códigos IBM lidos (zeros à esquerda, não dígitos)
+	IBM validation.IBMOptions
+}
+
+// DefaultTableOptions retorna as opções usadas quando nada é configurado
+func DefaultTableOptions() TableOptions {
+	return TableOptions{
+		IBMColumns: DefaultIBMColumns,
+		EANColumns: DefaultEANColumns,
+		HeaderRow:  1,
+		IBM:        validation.DefaultIBMOptions(),
+	}
+}
+
+// withDefaults preenche as opções não informadas com os valores padrão
+func (o TableOptions) withDefaults() TableOptions {
+	if len(o.IBMColumns) == 0 {
+		o.IBMColumns = DefaultIBMColumns
+	}
+	if len(o.EANColumns) == 0 {
+		o.EANColumns = DefaultEANColumns
+	}
+	if o.HeaderRow <= 0 {
+		o.HeaderRow = 1
+	}
+	return o
+}
+
+// findColumns encontra os índices das colunas do IBM e do código de barras no cabeçalho.
+// Quando mais de um nome aceito está presente, vale o de maior prioridade.
+func (o TableOptions) findColumns(header []string) (imbLojaIdx, codigoBarrasIdx int, err error) {
+	positions := make(map[string]int, len(header))
+	for i, col := range header {
+		name := normalizeColumnName(col)
+		if _, exists := positions[name]; !exists {
+			positions[name] = i
+		}
+	}
+
+	imbLojaIdx = findColumn(positions, o.IBMColumns)
+	if imbLojaIdx == -1 {
+		return -1, -1, fmt.Errorf("coluna do IBM não encontrada no cabeçalho (esperado: %s)", strings.Join(o.IBMColumns, ", "))
+	}
+	codigoBarrasIdx = findColumn(positions, o.EANColumns)
+	if codigoBarrasIdx == -1 {
+		return -1, -1, fmt.Errorf("coluna do código de barras não encontrada no cabeçalho (esperado: %s)", strings.Join(o.EANColumns, ", "))
+	}
+
+	return imbLojaIdx, codigoBarrasIdx, nil
+}
+
+// findColumn retorna a posição do primeiro nome aceito presente no cabeçalho, ou -1
+func findColumn(positions map[string]int, names []string) int {
+	for _, name := range names {
+		if idx, ok := positions[normalizeColumnName(name)]; ok {
+			return idx
+		}
+	}
+	return -1
+}
+
+// normalizeColumnName compara nomes de coluna sem diferenciar maiúsculas e espaços nas pontas
+func normalizeColumnName(name string) string {
+	return strings.ToUpper(strings.TrimSpace(strings.TrimPrefix(name, "\uFEFF")))
+}
+
+// selectSheets retorna as planilhas a ler conforme Sheet e AllSheets
+func (o TableOptions) selectSheets(sheets []string) ([]string, error) {
+	if len(sheets) == 0 {
+		return nil, fmt.Errorf("nenhuma planilha encontrada no arquivo")
+	}
+
+	if o.AllSheets {
+		return sheets, nil
+	}
+
+	if o.Sheet == "" {
+		return sheets[:1], nil
+	}
+
+	// Nome tem prioridade sobre índice (uma planilha pode se chamar "2")
+	for _, sheet := range sheets {
+		if strings.EqualFold(strings.TrimSpace(sheet), strings.TrimSpace(o.Sheet)) {
+			return []string{sheet}, nil
+		}
+	}
+
+	if index, err := strconv.Atoi(o.Sheet); err == nil {
+		if index < 1 || index > len(sheets) {
+			return nil, fmt.Errorf("planilha %d não existe (o arquivo tem %d planilhas)", index, len(sheets))
+		}
+		return []string{sheets[index-1]}, nil
+	}
+
+	return nil, fmt.Errorf("planilha %q não encontrada (disponíveis: %s)", o.Sheet, strings.Join(sheets, ", "))
+}
+
+// skipSheet registra por que uma planilha foi ignorada na leitura de todas as planilhas
+func skipSheet(sheet string, err error) {
+	log.Printf("⚠️  Planilha %q ignorada: %v", sheet, err)
+}
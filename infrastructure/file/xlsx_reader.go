@@ -2,7 +2,6 @@ package file
 
 import (
 	"fmt"
-	"strings"
 
 	"github.com/xuri/excelize/v2"
 )
@@ -13,10 +12,54 @@ type XLSXData struct {
 	ProductCodes []string
 	// Novo: mantém o relacionamento IBM -> Produtos
 	IBMToProducts map[string][]string
+	// Report é a análise prévia da leitura (linhas ignoradas, duplicatas, lojas)
+	Report *InputReport
+	// Rows associa cada par às linhas de origem no arquivo
+	Rows PairRows
 }
 
+// rawCellValues lê o valor gravado na célula em vez do valor formatado para exibição
+var rawCellValues = excelize.Options{RawCellValue: true}
+
 // ReadXLSX lê um arquivo XLSX e extrai os dados das colunas IMBLOJA e CODIGOBARRAS
+// da primeira planilha
 func ReadXLSX(filename string) (*XLSXData, error) {
+	return ReadXLSXWithOptions(filename, DefaultTableOptions())
+}
+
+// ReadXLSXWithOptions lê um arquivo XLSX usando os nomes de coluna, a planilha e a
+// linha de cabeçalho configurados em opts. Pares IBM/EAN repetidos são processados
+// uma única vez e listados em Report.DuplicatePairs.
+func ReadXLSXWithOptions(filename string, opts TableOptions) (*XLSXData, error) {
+	collector, err := collectXLSX(filename, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return collector.data(), nil
+}
+
+// ReadXLSXPairs lê um arquivo XLSX e retorna pares específicos de IBM e Produto
+// Esta função mantém as combinações exatas do arquivo, sem criar todas as combinações
+func ReadXLSXPairs(filename string) (map[string][]string, error) {
+	return ReadXLSXPairsWithOptions(filename, DefaultTableOptions())
+}
+
+// ReadXLSXPairsWithOptions lê os pares IBM/Produto (mantendo duplicatas) das
+// planilhas selecionadas em opts
+func ReadXLSXPairsWithOptions(filename string, opts TableOptions) (map[string][]string, error) {
+	collector, err := collectXLSX(filename, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return collector.ibmToProducts, nil
+}
+
+// collectXLSX lê as planilhas selecionadas em opts para um tableCollector
+func collectXLSX(filename string, opts TableOptions) (*tableCollector, error) {
+	opts = opts.withDefaults()
+
 	// Abrir o arquivo XLSX
 	f, err := excelize.OpenFile(filename)
 	if err != nil {
@@ -28,72 +71,62 @@ func ReadXLSX(filename string) (*XLSXData, error) {
 		}
 	}()
 
-	// Obter a primeira planilha
-	sheets := f.GetSheetList()
-	if len(sheets) == 0 {
-		return nil, fmt.Errorf("nenhuma planilha encontrada no arquivo")
-	}
-
-	sheetName := sheets[0]
-
-	// Ler todas as linhas
-	rows, err := f.GetRows(sheetName)
+	sheets, err := opts.selectSheets(f.GetSheetList())
 	if err != nil {
-		return nil, fmt.Errorf("erro ao ler linhas: %w", err)
+		return nil, err
 	}
 
-	if len(rows) == 0 {
-		return nil, fmt.Errorf("arquivo vazio")
-	}
+	collector := newTableCollector(filename, opts)
 
-	// Encontrar índices das colunas IMBLOJA e CODIGOBARRAS
-	header := rows[0]
-	imbLojaIdx := -1
-	codigoBarrasIdx := -1
-
-	for i, col := range header {
-		colUpper := strings.ToUpper(strings.TrimSpace(col))
-		if colUpper == "IMBLOJA" {
-			imbLojaIdx = i
-		} else if colUpper == "CODIGOBARRAS" {
-			codigoBarrasIdx = i
+	for _, sheetName := range sheets {
+		err := readSheet(f, sheetName, opts, collector)
+		if err == nil {
+			continue
+		}
+		if !opts.AllSheets {
+			return nil, err
 		}
+		skipSheet(sheetName, err)
 	}
 
-	if imbLojaIdx == -1 {
-		return nil, fmt.Errorf("coluna IMBLOJA não encontrada no cabeçalho")
-	}
-	if codigoBarrasIdx == -1 {
-		return nil, fmt.Errorf("coluna CODIGOBARRAS não encontrada no cabeçalho")
+	if len(collector.report.Sheets) == 0 {
+		return nil, fmt.Errorf("nenhuma planilha com as colunas de IBM e código de barras")
 	}
 
-	// Mapear IBM codes para seus produtos
-	ibmToProducts := make(map[string][]string)
+	return collector, nil
+}
 
-	// Processar linhas de dados (pular cabeçalho)
-	for i := 1; i < len(rows); i++ {
-		row := rows[i]
+// readSheet lê os pares de uma planilha, a partir da linha seguinte ao cabeçalho
+func readSheet(f *excelize.File, sheetName string, opts TableOptions, collector *tableCollector) error {
+	// Ler todas as linhas com o valor bruto das células: o formato numérico do Excel
+	// (notação científica, separador de milhar) corromperia os códigos
+	rows, err := f.GetRows(sheetName, rawCellValues)
+	if err != nil {
+		return fmt.Errorf("erro ao ler linhas: %w", err)
+	}
 
-		// Verificar se a linha tem colunas suficientes
-		if len(row) <= imbLojaIdx || len(row) <= codigoBarrasIdx {
-			continue
-		}
+	if len(rows) < opts.HeaderRow {
+		return fmt.Errorf("arquivo vazio")
+	}
 
-		ibmCode := strings.TrimSpace(row[imbLojaIdx])
-		productCode := strings.TrimSpace(row[codigoBarrasIdx])
+	// Encontrar índices das colunas de IBM e código de barras
+	imbLojaIdx, codigoBarrasIdx, err := opts.findColumns(rows[opts.HeaderRow-1])
+	if err != nil {
+		return err
+	}
 
-		// Ignorar linhas vazias
-		if ibmCode == "" || productCode == "" {
-			continue
-		}
+	collector.addSheet(sheetName)
 
-		// Adicionar ao mapa
-		if _, exists := ibmToProducts[ibmCode]; !exists {
-			ibmToProducts[ibmCode] = make([]string, 0)
-		}
-		ibmToProducts[ibmCode] = append(ibmToProducts[ibmCode], productCode)
+	// Processar linhas de dados (pular cabeçalho e linhas de título)
+	for i := opts.HeaderRow; i < len(rows); i++ {
+		collector.add(RowRef{Sheet: sheetName, Row: i + 1}, rows[i], imbLojaIdx, codigoBarrasIdx)
 	}
 
+	return nil
+}
+
+// newXLSXData monta as listas únicas de IBMs e produtos a partir do relacionamento
+func newXLSXData(ibmToProducts map[string][]string) *XLSXData {
 	// Converter mapa para listas
 	// O usecase espera todas as combinações, então vamos criar listas únicas
 	ibmCodesMap := make(map[string]bool)
@@ -121,88 +154,5 @@ func ReadXLSX(filename string) (*XLSXData, error) {
 		IBMCodes:      ibmCodes,
 		ProductCodes:  productCodes,
 		IBMToProducts: ibmToProducts, // Mantém o relacionamento original
-	}, nil
-}
-
-// ReadXLSXPairs lê um arquivo XLSX e retorna pares específicos de IBM e Produto
-// Esta função mantém as combinações exatas do arquivo, sem criar todas as combinações
-func ReadXLSXPairs(filename string) (map[string][]string, error) {
-	// Abrir o arquivo XLSX
-	f, err := excelize.OpenFile(filename)
-	if err != nil {
-		return nil, fmt.Errorf("erro ao abrir arquivo XLSX: %w", err)
-	}
-	defer func() {
-		if err := f.Close(); err != nil {
-			fmt.Printf("Erro ao fechar arquivo: %v\n", err)
-		}
-	}()
-
-	// Obter a primeira planilha
-	sheets := f.GetSheetList()
-	if len(sheets) == 0 {
-		return nil, fmt.Errorf("nenhuma planilha encontrada no arquivo")
-	}
-
-	sheetName := sheets[0]
-
-	// Ler todas as linhas
-	rows, err := f.GetRows(sheetName)
-	if err != nil {
-		return nil, fmt.Errorf("erro ao ler linhas: %w", err)
-	}
-
-	if len(rows) == 0 {
-		return nil, fmt.Errorf("arquivo vazio")
-	}
-
-	// Encontrar índices das colunas IMBLOJA e CODIGOBARRAS
-	header := rows[0]
-	imbLojaIdx := -1
-	codigoBarrasIdx := -1
-
-	for i, col := range header {
-		colUpper := strings.ToUpper(strings.TrimSpace(col))
-		if colUpper == "IMBLOJA" {
-			imbLojaIdx = i
-		} else if colUpper == "CODIGOBARRAS" {
-			codigoBarrasIdx = i
-		}
-	}
-
-	if imbLojaIdx == -1 {
-		return nil, fmt.Errorf("coluna IMBLOJA não encontrada no cabeçalho")
-	}
-	if codigoBarrasIdx == -1 {
-		return nil, fmt.Errorf("coluna CODIGOBARRAS não encontrada no cabeçalho")
-	}
-
-	// Mapear IBM codes para seus produtos (mantendo duplicatas)
-	ibmToProducts := make(map[string][]string)
-
-	// Processar linhas de dados (pular cabeçalho)
-	for i := 1; i < len(rows); i++ {
-		row := rows[i]
-
-		// Verificar se a linha tem colunas suficientes
-		if len(row) <= imbLojaIdx || len(row) <= codigoBarrasIdx {
-			continue
-		}
-
-		ibmCode := strings.TrimSpace(row[imbLojaIdx])
-		productCode := strings.TrimSpace(row[codigoBarrasIdx])
-
-		// Ignorar linhas vazias
-		if ibmCode == "" || productCode == "" {
-			continue
-		}
-
-		// Adicionar ao mapa
-		if _, exists := ibmToProducts[ibmCode]; !exists {
-			ibmToProducts[ibmCode] = make([]string, 0)
-		}
-		ibmToProducts[ibmCode] = append(ibmToProducts[ibmCode], productCode)
 	}
-
-	return ibmToProducts, nil
 }
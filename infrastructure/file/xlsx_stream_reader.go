@@ -0,0 +1,170 @@
+package file
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// XLSXPairReader lê os pares IBM/EAN de um arquivo XLSX linha a linha, usando o
+// iterador de linhas do excelize. Ao contrário de ReadXLSX, a planilha nunca é
+// carregada inteira em memória.
+type XLSXPairReader struct {
+	f    *excelize.File
+	opts TableOptions
+
+	// Planilhas a ler; a atual é sheets[0]
+	sheets []string
+	read   int // planilhas com cabeçalho válido
+
+	rows            *excelize.Rows
+	imbLojaIdx      int
+	codigoBarrasIdx int
+}
+
+// OpenXLSXPairReader abre o arquivo XLSX e posiciona o reader após o cabeçalho
+// da primeira planilha selecionada em opts
+func OpenXLSXPairReader(filename string, opts TableOptions) (*XLSXPairReader, error) {
+	opts = opts.withDefaults()
+
+	f, err := excelize.OpenFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao abrir arquivo XLSX: %w", err)
+	}
+
+	reader := &XLSXPairReader{f: f, opts: opts}
+
+	reader.sheets, err = opts.selectSheets(f.GetSheetList())
+	if err == nil {
+		err = reader.openNextSheet()
+	}
+	if err != nil {
+		reader.Close()
+		return nil, err
+	}
+
+	return reader, nil
+}
+
+// openNextSheet inicia o iterador da próxima planilha com cabeçalho válido.
+// Com AllSheets, planilhas sem as colunas são ignoradas; sem planilhas restantes,
+// r.rows fica nil.
+func (r *XLSXPairReader) openNextSheet() error {
+	for len(r.sheets) > 0 {
+		sheetName := r.sheets[0]
+		r.sheets = r.sheets[1:]
+
+		err := r.openSheet(sheetName)
+		if err == nil {
+			r.read++
+			return nil
+		}
+		if !r.opts.AllSheets {
+			return err
+		}
+		skipSheet(sheetName, err)
+		if err := r.closeRows(); err != nil {
+			return err
+		}
+	}
+
+	if r.read == 0 {
+		return fmt.Errorf("nenhuma planilha com as colunas de IBM e código de barras")
+	}
+	return nil
+}
+
+// openSheet inicia o iterador de linhas da planilha e localiza as colunas no cabeçalho
+func (r *XLSXPairReader) openSheet(sheetName string) error {
+	if err := r.closeRows(); err != nil {
+		return err
+	}
+
+	rows, err := r.f.Rows(sheetName)
+	if err != nil {
+		return fmt.Errorf("erro ao ler linhas: %w", err)
+	}
+	r.rows = rows
+
+	// Avançar até a linha de cabeçalho
+	for i := 0; i < r.opts.HeaderRow; i++ {
+		if !rows.Next() {
+			if err := rows.Error(); err != nil {
+				return fmt.Errorf("erro ao ler cabeçalho: %w", err)
+			}
+			return fmt.Errorf("arquivo vazio")
+		}
+	}
+
+	header, err := rows.Columns(rawCellValues)
+	if err != nil {
+		return fmt.Errorf("erro ao ler cabeçalho: %w", err)
+	}
+
+	// Encontrar índices das colunas de IBM e código de barras
+	r.imbLojaIdx, r.codigoBarrasIdx, err = r.opts.findColumns(header)
+	return err
+}
+
+// Next retorna o próximo par IBM/EAN, ignorando linhas sem IBM ou sem código de barras.
+// Pares repetidos não são removidos (exigiria manter todos os pares em memória).
+// Ao final da última planilha retorna io.EOF.
+func (r *XLSXPairReader) Next() (ibm, ean string, err error) {
+	for r.rows != nil {
+		for r.rows.Next() {
+			row, err := r.rows.Columns(rawCellValues)
+			if err != nil {
+				return "", "", fmt.Errorf("erro ao ler linhas: %w", err)
+			}
+
+			ibm = r.opts.IBM.Normalize(cell(row, r.imbLojaIdx))
+			ean = strings.TrimSpace(cell(row, r.codigoBarrasIdx))
+
+			// Ignorar linhas vazias
+			if ibm == "" || ean == "" {
+				continue
+			}
+
+			return ibm, ean, nil
+		}
+
+		if err := r.rows.Error(); err != nil {
+			return "", "", fmt.Errorf("erro ao ler linhas: %w", err)
+		}
+		if err := r.closeRows(); err != nil {
+			return "", "", err
+		}
+		if err := r.openNextSheet(); err != nil {
+			return "", "", err
+		}
+	}
+
+	return "", "", io.EOF
+}
+
+// closeRows fecha o iterador da planilha atual
+func (r *XLSXPairReader) closeRows() error {
+	if r.rows == nil {
+		return nil
+	}
+	rows := r.rows
+	r.rows = nil
+	if err := rows.Close(); err != nil {
+		return fmt.Errorf("erro ao fechar iterador de linhas: %w", err)
+	}
+	return nil
+}
+
+// Close libera o iterador e o arquivo (incluindo os temporários criados pelo excelize)
+func (r *XLSXPairReader) Close() error {
+	if err := r.closeRows(); err != nil {
+		r.f.Close()
+		return err
+	}
+	if err := r.f.Close(); err != nil {
+		return fmt.Errorf("erro ao fechar arquivo XLSX: %w", err)
+	}
+	return nil
+}
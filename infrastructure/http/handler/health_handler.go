@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"time"
+)
+
+// HealthHandler expõe as rotas de verificação de saúde da aplicação
+type HealthHandler struct {
+	db *sql.DB
+}
+
+// NewHealthHandler cria uma nova instância do handler de saúde
+func NewHealthHandler(db *sql.DB) *HealthHandler {
+	return &HealthHandler{
+		db: db,
+	}
+}
+
+// Liveness indica apenas que o processo está respondendo
+func (h *HealthHandler) Liveness(w http.ResponseWriter, r *http.Request) {
+	writeStatus(w, http.StatusOK, "ok")
+}
+
+// Readiness verifica se o banco de dados está acessível
+func (h *HealthHandler) Readiness(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.db.PingContext(ctx); err != nil {
+		writeStatus(w, http.StatusServiceUnavailable, "banco de dados indisponível: "+err.Error())
+		return
+	}
+
+	writeStatus(w, http.StatusOK, "ok")
+}
+
+// writeStatus escreve uma resposta JSON simples com o status informado
+func writeStatus(w http.ResponseWriter, statusCode int, status string) {
+	writeJSON(w, statusCode, map[string]string{"status": status})
+}
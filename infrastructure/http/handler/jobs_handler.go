@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.thiagohmm.com.br/cargaparcial/usecase"
+	"github.thiagohmm.com.br/cargaparcial/usecase/dto"
+)
+
+// JobsHandler gerencia as requisições HTTP de jobs assíncronos
+type JobsHandler struct {
+	jobManager *usecase.JobManager
+}
+
+// NewJobsHandler cria uma nova instância do handler de jobs
+func NewJobsHandler(jobManager *usecase.JobManager) *JobsHandler {
+	return &JobsHandler{
+		jobManager: jobManager,
+	}
+}
+
+// Submit enfileira uma nova carga e retorna o ID do job (POST /api/jobs)
+func (h *JobsHandler) Submit(w http.ResponseWriter, r *http.Request) {
+	var input dto.ProcessProductsInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Erro ao decodificar JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if msg := validateInput(input); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
+		return
+	}
+
+	id, err := h.jobManager.Submit(input)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, usecase.ErrJobStoreFull) || errors.Is(err, usecase.ErrJobManagerClosed) {
+			status = http.StatusServiceUnavailable
+		}
+		http.Error(w, "Erro ao enfileirar job: "+err.Error(), status)
+		return
+	}
+
+	w.Header().Set("Location", "/api/jobs/"+id)
+	writeJSON(w, http.StatusAccepted, dto.JobSubmittedDTO{
+		ID:     id,
+		Status: usecase.JobStateQueued,
+	})
+}
+
+// Status retorna o estado do job (GET /api/jobs/{id})
+func (h *JobsHandler) Status(w http.ResponseWriter, r *http.Request) {
+	status, err := h.jobManager.Status(r.PathValue("id"))
+	if err != nil {
+		writeJobError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, status)
+}
+
+// Result retorna o resultado do job finalizado (GET /api/jobs/{id}/result)
+func (h *JobsHandler) Result(w http.ResponseWriter, r *http.Request) {
+	output, state, err := h.jobManager.Result(r.PathValue("id"))
+	if errors.Is(err, usecase.ErrJobNotFound) {
+		writeJobError(w, err)
+		return
+	}
+
+	switch state {
+	case usecase.JobStateDone:
+		writeJSON(w, http.StatusOK, output)
+	case usecase.JobStateFailed:
+		http.Error(w, "Job falhou: "+err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, "Job ainda não finalizado (status: "+state+")", http.StatusConflict)
+	}
+}
+
+// writeJobError converte erros do gerenciador de jobs em respostas HTTP
+func writeJobError(w http.ResponseWriter, err error) {
+	if errors.Is(err, usecase.ErrJobNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
+
+// writeJSON escreve o valor informado como resposta JSON
+func writeJSON(w http.ResponseWriter, statusCode int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(v)
+}
@@ -34,18 +34,13 @@ func (h *ProcessProductsHandler) Handle(w http.ResponseWriter, r *http.Request)
 	}
 
 	// Validar entrada
-	if len(input.IBMCodes) == 0 {
-		http.Error(w, "Lista de códigos IBM não pode estar vazia", http.StatusBadRequest)
+	if msg := validateInput(input); msg != "" {
+		http.Error(w, msg, http.StatusBadRequest)
 		return
 	}
 
-	if len(input.ProductCodes) == 0 {
-		http.Error(w, "Lista de códigos de produto não pode estar vazia", http.StatusBadRequest)
-		return
-	}
-
-	// Executar use case
-	output, err := h.useCase.Execute(input)
+	// Executar use case (cancelado se o cliente desconectar)
+	output, err := h.useCase.Execute(r.Context(), input)
 	if err != nil {
 		http.Error(w, "Erro ao processar produtos: "+err.Error(), http.StatusInternalServerError)
 		return
@@ -60,3 +55,16 @@ func (h *ProcessProductsHandler) Handle(w http.ResponseWriter, r *http.Request)
 		return
 	}
 }
+
+// validateInput valida a entrada e retorna a mensagem de erro, se houver
+func validateInput(input dto.ProcessProductsInput) string {
+	if len(input.IBMCodes) == 0 {
+		return "Lista de códigos IBM não pode estar vazia"
+	}
+
+	if len(input.ProductCodes) == 0 {
+		return "Lista de códigos de produto não pode estar vazia"
+	}
+
+	return ""
+}
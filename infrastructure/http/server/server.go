@@ -0,0 +1,93 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.thiagohmm.com.br/cargaparcial/infrastructure/http/handler"
+)
+
+// Config contém as configurações do servidor HTTP
+type Config struct {
+	Addr            string
+	ReadTimeout     time.Duration
+	WriteTimeout    time.Duration
+	IdleTimeout     time.Duration
+	ShutdownTimeout time.Duration
+}
+
+// Server encapsula o http.Server com desligamento gracioso
+type Server struct {
+	httpServer      *http.Server
+	shutdownTimeout time.Duration
+}
+
+// NewRouter registra as rotas da API
+func NewRouter(
+	processProductsHandler *handler.ProcessProductsHandler,
+	jobsHandler *handler.JobsHandler,
+	healthHandler *handler.HealthHandler,
+) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/process-products", processProductsHandler.Handle)
+	mux.HandleFunc("POST /api/jobs", jobsHandler.Submit)
+	mux.HandleFunc("GET /api/jobs/{id}", jobsHandler.Status)
+	mux.HandleFunc("GET /api/jobs/{id}/result", jobsHandler.Result)
+	mux.HandleFunc("GET /health", healthHandler.Liveness)
+	mux.HandleFunc("GET /ready", healthHandler.Readiness)
+	return mux
+}
+
+// NewServer cria uma nova instância do servidor HTTP
+func NewServer(config Config, router http.Handler) *Server {
+	return &Server{
+		httpServer: &http.Server{
+			Addr:              config.Addr,
+			Handler:           router,
+			ReadTimeout:       config.ReadTimeout,
+			ReadHeaderTimeout: config.ReadTimeout,
+			WriteTimeout:      config.WriteTimeout,
+			IdleTimeout:       config.IdleTimeout,
+		},
+		shutdownTimeout: config.ShutdownTimeout,
+	}
+}
+
+// Run inicia o servidor e bloqueia até o contexto ser cancelado.
+// No cancelamento, para de aceitar conexões e aguarda as requisições
+// em andamento (inclusive execuções do use case) terminarem.
+func (s *Server) Run(ctx context.Context) error {
+	serverErr := make(chan error, 1)
+	go func() {
+		log.Printf("🌐 Servidor HTTP escutando em %s", s.httpServer.Addr)
+		if err := s.httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+		close(serverErr)
+	}()
+
+	select {
+	case err := <-serverErr:
+		if err != nil {
+			return fmt.Errorf("erro ao iniciar servidor HTTP: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+	}
+
+	log.Printf("Desligando servidor HTTP (aguardando até %s pelas requisições em andamento)...", s.shutdownTimeout)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
+	defer cancel()
+
+	if err := s.httpServer.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("erro ao desligar servidor HTTP: %w", err)
+	}
+
+	log.Println("✓ Servidor HTTP desligado")
+	return nil
+}
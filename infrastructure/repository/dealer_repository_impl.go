@@ -1,8 +1,10 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"strings"
 
 	"github.thiagohmm.com.br/cargaparcial/domain/entities"
 	"github.thiagohmm.com.br/cargaparcial/domain/repositories"
@@ -10,8 +12,8 @@ import (
 
 // DealerRepositoryImpl implementa o DealerRepository
 type DealerRepositoryImpl struct {
-	db            *sql.DB
-	stmtGetByIBM  *sql.Stmt
+	db           *sql.DB
+	stmtGetByIBM *sql.Stmt
 }
 
 // NewDealerRepository cria uma nova instância do repositório
@@ -19,21 +21,21 @@ func NewDealerRepository(db *sql.DB) repositories.DealerRepository {
 	repo := &DealerRepositoryImpl{
 		db: db,
 	}
-	
+
 	// Pré-compilar query de busca por IBM
 	var err error
 	repo.stmtGetByIBM, err = db.Prepare(`SELECT IdRevendedor, CodigoIBM FROM Revendedor WHERE CodigoIBM = :1`)
 	if err != nil {
 		panic(fmt.Sprintf("Erro ao preparar statement GetByIBM: %v", err))
 	}
-	
+
 	return repo
 }
 
 // GetByIBM busca um revendedor pelo código IBM
-func (r *DealerRepositoryImpl) GetByIBM(ibm string) (*entities.Dealer, error) {
+func (r *DealerRepositoryImpl) GetByIBM(ctx context.Context, ibm string) (*entities.Dealer, error) {
 	var dealer entities.Dealer
-	err := r.stmtGetByIBM.QueryRow(ibm).Scan(&dealer.ID, &dealer.IBM)
+	err := r.stmtGetByIBM.QueryRowContext(ctx, ibm).Scan(&dealer.ID, &dealer.IBM)
 
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("revendedor não encontrado para IBM: %s", ibm)
@@ -45,3 +47,78 @@ func (r *DealerRepositoryImpl) GetByIBM(ibm string) (*entities.Dealer, error) {
 
 	return &dealer, nil
 }
+
+// GetByIBMs busca os revendedores de vários IBMs em consultas com lista IN, em blocos
+// (o Oracle limita a lista IN a 1000 expressões)
+func (r *DealerRepositoryImpl) GetByIBMs(ctx context.Context, ibms []string) (map[string]*entities.Dealer, error) {
+	const chunkSize = 500
+
+	dealers := make(map[string]*entities.Dealer, len(ibms))
+
+	for i := 0; i < len(ibms); i += chunkSize {
+		end := i + chunkSize
+		if end > len(ibms) {
+			end = len(ibms)
+		}
+		chunk := ibms[i:end]
+
+		var query strings.Builder
+		query.WriteString("SELECT IdRevendedor, CodigoIBM FROM Revendedor WHERE CodigoIBM IN (")
+
+		args := make([]interface{}, len(chunk))
+		for idx, ibm := range chunk {
+			if idx > 0 {
+				query.WriteString(", ")
+			}
+			query.WriteString(fmt.Sprintf(":%d", idx+1))
+			args[idx] = ibm
+		}
+		query.WriteString(")")
+
+		rows, err := r.db.QueryContext(ctx, query.String(), args...)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao buscar revendedores por IBM em lote: %w", err)
+		}
+
+		for rows.Next() {
+			var dealer entities.Dealer
+			if err := rows.Scan(&dealer.ID, &dealer.IBM); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("erro ao escanear revendedor: %w", err)
+			}
+			dealers[dealer.IBM] = &dealer
+		}
+
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, fmt.Errorf("erro ao iterar revendedores: %w", err)
+		}
+	}
+
+	return dealers, nil
+}
+
+// ListIBMs retorna todos os códigos IBM cadastrados em Revendedor
+func (r *DealerRepositoryImpl) ListIBMs(ctx context.Context) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT CodigoIBM FROM Revendedor WHERE CodigoIBM IS NOT NULL")
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar IBMs de revendedores: %w", err)
+	}
+	defer rows.Close()
+
+	var ibms []string
+	for rows.Next() {
+		var ibm string
+		if err := rows.Scan(&ibm); err != nil {
+			return nil, fmt.Errorf("erro ao escanear IBM: %w", err)
+		}
+		ibms = append(ibms, ibm)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("erro ao iterar IBMs: %w", err)
+	}
+
+	return ibms, nil
+}
@@ -1,19 +1,26 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"slices"
+	"sort"
 	"strings"
 
+	"github.com/sijms/go-ora/v2/network"
 	"github.thiagohmm.com.br/cargaparcial/domain/entities"
 	"github.thiagohmm.com.br/cargaparcial/domain/repositories"
 )
 
 // ProductDealerRepositoryImpl implementa o ProductDealerRepository
 type ProductDealerRepositoryImpl struct {
-	db           *sql.DB
-	stmtExists   *sql.Stmt
-	stmtCreate   *sql.Stmt
+	db        *sql.DB
+	stmtMerge *sql.Stmt
+
+	stmtDeactivate *sql.Stmt
+	stmtDelete     *sql.Stmt
 }
 
 // NewProductDealerRepository cria uma nova instância do repositório
@@ -21,87 +28,366 @@ func NewProductDealerRepository(db *sql.DB) repositories.ProductDealerRepository
 	repo := &ProductDealerRepositoryImpl{
 		db: db,
 	}
-	
-	// Pré-compilar query de verificação de existência
+
+	// Uma relação é ativa com StatusProdutoRevendedor diferente de 0 e de nulo, em todas as
+	// consultas e comandos abaixo (findStates inclusive)
+
+	// Pré-compilar MERGE idempotente: cria a relação ou reativa a inativa (status 0 ou nulo)
 	var err error
-	repo.stmtExists, err = db.Prepare(`SELECT COUNT(*) FROM ProdutoRevendedor WHERE IdProduto = :1 AND IdRevendedor = :2`)
+	repo.stmtMerge, err = db.Prepare(`
+		MERGE INTO ProdutoRevendedor t
+		USING (SELECT :1 AS IdProduto, :2 AS IdRevendedor FROM DUAL) s
+		ON (t.IdProduto = s.IdProduto AND t.IdRevendedor = s.IdRevendedor)
+		WHEN MATCHED THEN
+			UPDATE SET t.StatusProdutoRevendedor = 1 WHERE NVL(t.StatusProdutoRevendedor, 0) = 0
+		WHEN NOT MATCHED THEN
+			INSERT (IdProduto, IdRevendedor, StatusProdutoRevendedor)
+			VALUES (s.IdProduto, s.IdRevendedor, 1)
+	`)
 	if err != nil {
-		panic(fmt.Sprintf("Erro ao preparar statement Exists: %v", err))
+		panic(fmt.Sprintf("Erro ao preparar statement Merge: %v", err))
 	}
-	
-	// Pré-compilar query de insert único
-	repo.stmtCreate, err = db.Prepare(`
-		INSERT INTO ProdutoRevendedor (IdProduto, IdRevendedor, StatusProdutoRevendedor)
-		VALUES (:1, :2, :3)
+
+	// Pré-compilar desativação e exclusão (modo de desvinculação)
+	repo.stmtDeactivate, err = db.Prepare(`
+		UPDATE ProdutoRevendedor SET StatusProdutoRevendedor = 0
+		WHERE IdProduto = :1 AND IdRevendedor = :2 AND NVL(StatusProdutoRevendedor, 0) <> 0
 	`)
 	if err != nil {
-		panic(fmt.Sprintf("Erro ao preparar statement Create: %v", err))
+		panic(fmt.Sprintf("Erro ao preparar statement Deactivate: %v", err))
 	}
-	
+
+	repo.stmtDelete, err = db.Prepare(`DELETE FROM ProdutoRevendedor WHERE IdProduto = :1 AND IdRevendedor = :2`)
+	if err != nil {
+		panic(fmt.Sprintf("Erro ao preparar statement Delete: %v", err))
+	}
+
 	return repo
 }
 
-// Exists verifica se existe uma relação entre produto e revendedor
-func (r *ProductDealerRepositoryImpl) Exists(productID, dealerID int) (bool, error) {
-	var count int
-	err := r.stmtExists.QueryRow(productID, dealerID).Scan(&count)
+// EnsureBatch garante que as relações existam e estejam ativas usando MERGE com array bind.
+// Retorna, na mesma ordem da entrada, se cada relação foi criada, reativada ou já estava ativa.
+// As relações existentes são travadas (SELECT ... FOR UPDATE) na mesma transação do MERGE:
+// uma execução concorrente sobre os mesmos pares espera o commit e lê o estado já gravado.
+func (r *ProductDealerRepositoryImpl) EnsureBatch(ctx context.Context, productDealers []*entities.ProductDealer) ([]entities.RelationStatus, error) {
+	if len(productDealers) == 0 {
+		return nil, nil
+	}
+
+	// Remover pares repetidos: o MERGE não aceita a mesma linha de origem duas vezes
+	unique := uniqueProductDealers(productDealers)
+
+	states, err := r.ensureBatch(ctx, unique)
+	if err != nil && isUniqueViolation(err) {
+		// Outra sessão inseriu um dos pares entre a leitura e o MERGE; na nova
+		// transação a relação já existe, é travada e classificada como já ativa
+		states, err = r.ensureBatch(ctx, unique)
+	}
 	if err != nil {
-		return false, fmt.Errorf("erro ao verificar existência de ProductDealer: %w", err)
+		return nil, err
 	}
 
-	return count > 0, nil
+	return classifyRelations(productDealers, states), nil
 }
 
-// Create cria uma nova relação entre produto e revendedor
-func (r *ProductDealerRepositoryImpl) Create(productDealer *entities.ProductDealer) error {
-	_, err := r.stmtCreate.Exec(productDealer.ProductID, productDealer.DealerID, productDealer.IsActive)
+// ensureBatch trava as relações existentes, executa o MERGE e retorna o estado
+// anterior de cada par, tudo na mesma transação
+func (r *ProductDealerRepositoryImpl) ensureBatch(ctx context.Context, unique []*entities.ProductDealer) (map[productDealerKey]bool, error) {
+	var states map[productDealerKey]bool
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		states, err = r.findStates(ctx, tx, unique, true)
+		if err != nil {
+			return err
+		}
+
+		productIDs := make([]int, len(unique))
+		dealerIDs := make([]int, len(unique))
+		for i, pd := range unique {
+			productIDs[i] = pd.ProductID
+			dealerIDs[i] = pd.DealerID
+		}
+
+		// Passar slices como parâmetros faz o go-ora executar o MERGE com array bind
+		if _, err := tx.StmtContext(ctx, r.stmtMerge).ExecContext(ctx, productIDs, dealerIDs); err != nil {
+			return fmt.Errorf("erro ao gravar ProductDealers em batch (MERGE): %w", err)
+		}
+		return nil
+	})
+	return states, err
+}
+
+// PlanBatch consulta o estado atual das relações e retorna, na mesma ordem da entrada,
+// se cada uma seria criada, reativada ou já está ativa. Nenhuma linha é gravada.
+func (r *ProductDealerRepositoryImpl) PlanBatch(ctx context.Context, productDealers []*entities.ProductDealer) ([]entities.RelationStatus, error) {
+	if len(productDealers) == 0 {
+		return nil, nil
+	}
+
+	states, err := r.findStates(ctx, r.db, uniqueProductDealers(productDealers), false)
 	if err != nil {
-		return fmt.Errorf("erro ao criar ProductDealer: %w", err)
+		return nil, err
 	}
 
-	return nil
+	return classifyRelations(productDealers, states), nil
+}
+
+// DeactivateBatch marca as relações como inativas com UPDATE em array bind.
+// Retorna, na mesma ordem da entrada, se cada relação foi desativada, já estava inativa ou não existia.
+func (r *ProductDealerRepositoryImpl) DeactivateBatch(ctx context.Context, productDealers []*entities.ProductDealer) ([]entities.RelationStatus, error) {
+	return r.removeBatch(ctx, productDealers, r.stmtDeactivate, func(active bool) entities.RelationStatus {
+		if active {
+			return entities.RelationDeactivated
+		}
+		return entities.RelationAlreadyInactive
+	})
 }
 
-// CreateBatch cria múltiplas relações em batch (mais eficiente)
-func (r *ProductDealerRepositoryImpl) CreateBatch(productDealers []*entities.ProductDealer) error {
+// DeleteBatch exclui as relações com DELETE em array bind.
+// Retorna, na mesma ordem da entrada, se cada relação foi excluída ou não existia.
+func (r *ProductDealerRepositoryImpl) DeleteBatch(ctx context.Context, productDealers []*entities.ProductDealer) ([]entities.RelationStatus, error) {
+	return r.removeBatch(ctx, productDealers, r.stmtDelete, func(bool) entities.RelationStatus {
+		return entities.RelationDeleted
+	})
+}
+
+// removeBatch executa a desativação ou exclusão das relações existentes e classifica
+// cada uma pelo estado anterior (classify recebe se a relação estava ativa). As relações
+// são travadas na mesma transação da alteração, como em EnsureBatch.
+func (r *ProductDealerRepositoryImpl) removeBatch(ctx context.Context, productDealers []*entities.ProductDealer, stmt *sql.Stmt, classify func(active bool) entities.RelationStatus) ([]entities.RelationStatus, error) {
 	if len(productDealers) == 0 {
+		return nil, nil
+	}
+
+	unique := uniqueProductDealers(productDealers)
+
+	var states map[productDealerKey]bool
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		states, err = r.findStates(ctx, tx, unique, true)
+		if err != nil {
+			return err
+		}
+
+		// Apenas as relações existentes são enviadas ao banco
+		productIDs := make([]int, 0, len(states))
+		dealerIDs := make([]int, 0, len(states))
+		for _, pd := range unique {
+			if _, exists := states[productDealerKey{productID: pd.ProductID, dealerID: pd.DealerID}]; exists {
+				productIDs = append(productIDs, pd.ProductID)
+				dealerIDs = append(dealerIDs, pd.DealerID)
+			}
+		}
+		if len(productIDs) == 0 {
+			return nil
+		}
+
+		// Passar slices como parâmetros faz o go-ora executar o comando com array bind
+		if _, err := tx.StmtContext(ctx, stmt).ExecContext(ctx, productIDs, dealerIDs); err != nil {
+			return fmt.Errorf("erro ao remover ProductDealers em batch: %w", err)
+		}
 		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]entities.RelationStatus, len(productDealers))
+	for i, pd := range productDealers {
+		active, exists := states[productDealerKey{productID: pd.ProductID, dealerID: pd.DealerID}]
+		if !exists {
+			statuses[i] = entities.RelationNotFound
+			continue
+		}
+		statuses[i] = classify(active)
+	}
+
+	return statuses, nil
+}
+
+// ListActiveProducts busca os produtos com relação ativa de cada revendedor, em blocos de revendedores
+func (r *ProductDealerRepositoryImpl) ListActiveProducts(ctx context.Context, dealerIDs []int) (map[int][]entities.Product, error) {
+	const chunkSize = 1000 // Limite do Oracle para IN clause
+
+	products := make(map[int][]entities.Product, len(dealerIDs))
+
+	for i := 0; i < len(dealerIDs); i += chunkSize {
+		end := i + chunkSize
+		if end > len(dealerIDs) {
+			end = len(dealerIDs)
+		}
+		chunk := dealerIDs[i:end]
+
+		var query strings.Builder
+		query.WriteString(`SELECT pr.IdRevendedor, pr.IdProduto, MIN(e.CODIGOBARRAS)
+			FROM ProdutoRevendedor pr
+			LEFT JOIN EmbalagemProduto e ON e.IDPRODUTO = pr.IdProduto
+			WHERE NVL(pr.StatusProdutoRevendedor, 0) <> 0 AND pr.IdRevendedor IN (`)
+
+		args := make([]interface{}, len(chunk))
+		for idx, dealerID := range chunk {
+			if idx > 0 {
+				query.WriteString(", ")
+			}
+			query.WriteString(fmt.Sprintf(":%d", idx+1))
+			args[idx] = dealerID
+		}
+		query.WriteString(") GROUP BY pr.IdRevendedor, pr.IdProduto")
+
+		rows, err := r.db.QueryContext(ctx, query.String(), args...)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao listar produtos ativos dos revendedores: %w", err)
+		}
+
+		for rows.Next() {
+			var dealerID int
+			var product entities.Product
+			var ean sql.NullString
+			if err := rows.Scan(&dealerID, &product.ID, &ean); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("erro ao escanear produto ativo: %w", err)
+			}
+			product.EAN = ean.String
+			products[dealerID] = append(products[dealerID], product)
+		}
+
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, fmt.Errorf("erro ao iterar produtos ativos: %w", err)
+		}
+	}
+
+	return products, nil
+}
+
+// uniqueProductDealers remove os pares repetidos, mantendo a ordem
+func uniqueProductDealers(productDealers []*entities.ProductDealer) []*entities.ProductDealer {
+	unique := make([]*entities.ProductDealer, 0, len(productDealers))
+	seen := make(map[productDealerKey]bool, len(productDealers))
+	for _, pd := range productDealers {
+		key := productDealerKey{productID: pd.ProductID, dealerID: pd.DealerID}
+		if !seen[key] {
+			seen[key] = true
+			unique = append(unique, pd)
+		}
+	}
+	return unique
+}
+
+// classifyRelations classifica cada relação pelo estado anterior à gravação
+func classifyRelations(productDealers []*entities.ProductDealer, states map[productDealerKey]bool) []entities.RelationStatus {
+	statuses := make([]entities.RelationStatus, len(productDealers))
+	for i, pd := range productDealers {
+		active, exists := states[productDealerKey{productID: pd.ProductID, dealerID: pd.DealerID}]
+		switch {
+		case !exists:
+			statuses[i] = entities.RelationCreated
+		case !active:
+			statuses[i] = entities.RelationReactivated
+		default:
+			statuses[i] = entities.RelationAlreadyActive
+		}
+	}
+	return statuses
+}
+
+// productDealerKey identifica uma relação produto-revendedor
+type productDealerKey struct {
+	productID int
+	dealerID  int
+}
+
+// queryer executa consultas fora (*sql.DB) ou dentro (*sql.Tx) de uma transação
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// inTx executa fn em uma transação, confirmada somente se fn não falhar
+func (r *ProductDealerRepositoryImpl) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("erro ao iniciar transação de ProductDealers: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("erro ao confirmar transação de ProductDealers: %w", err)
 	}
+	return nil
+}
+
+// findStates busca as relações existentes e se estão ativas (status nulo conta como
+// inativa), em blocos de pares. Com lock=true as relações ficam travadas até o fim da
+// transação; os pares são travados sempre na mesma ordem para evitar deadlocks.
+func (r *ProductDealerRepositoryImpl) findStates(ctx context.Context, q queryer, productDealers []*entities.ProductDealer, lock bool) (map[productDealerKey]bool, error) {
+	const chunkSize = 100 // 100 pares = 200 binds por consulta
+
+	if lock {
+		productDealers = slices.Clone(productDealers)
+		sort.Slice(productDealers, func(i, j int) bool {
+			if productDealers[i].ProductID != productDealers[j].ProductID {
+				return productDealers[i].ProductID < productDealers[j].ProductID
+			}
+			return productDealers[i].DealerID < productDealers[j].DealerID
+		})
+	}
+
+	states := make(map[productDealerKey]bool, len(productDealers))
 
-	// Para Oracle, vamos usar INSERT ALL
-	// INSERT ALL
-	//   INTO ProdutoRevendedor VALUES (?, ?, ?)
-	//   INTO ProdutoRevendedor VALUES (?, ?, ?)
-	// SELECT 1 FROM DUAL
-	
-	const batchSize = 100 // Oracle tem limite de 1000 binds, 100 * 3 = 300 é seguro
-	
-	for i := 0; i < len(productDealers); i += batchSize {
-		end := i + batchSize
+	for i := 0; i < len(productDealers); i += chunkSize {
+		end := i + chunkSize
 		if end > len(productDealers) {
 			end = len(productDealers)
 		}
-		
-		batch := productDealers[i:end]
-		
+		chunk := productDealers[i:end]
+
 		var query strings.Builder
-		query.WriteString("INSERT ALL\n")
-		
-		args := make([]interface{}, 0, len(batch)*3)
-		for idx, pd := range batch {
-			offset := idx * 3
-			query.WriteString(fmt.Sprintf("  INTO ProdutoRevendedor (IdProduto, IdRevendedor, StatusProdutoRevendedor) VALUES (:%d, :%d, :%d)\n", 
-				offset+1, offset+2, offset+3))
-			args = append(args, pd.ProductID, pd.DealerID, pd.IsActive)
-		}
-		
-		query.WriteString("SELECT 1 FROM DUAL")
-		
-		_, err := r.db.Exec(query.String(), args...)
+		query.WriteString("SELECT IdProduto, IdRevendedor, StatusProdutoRevendedor FROM ProdutoRevendedor WHERE (IdProduto, IdRevendedor) IN (")
+
+		args := make([]interface{}, 0, len(chunk)*2)
+		for idx, pd := range chunk {
+			if idx > 0 {
+				query.WriteString(", ")
+			}
+			query.WriteString(fmt.Sprintf("(:%d, :%d)", idx*2+1, idx*2+2))
+			args = append(args, pd.ProductID, pd.DealerID)
+		}
+		query.WriteString(")")
+		if lock {
+			query.WriteString(" ORDER BY IdProduto, IdRevendedor FOR UPDATE")
+		}
+
+		rows, err := q.QueryContext(ctx, query.String(), args...)
 		if err != nil {
-			return fmt.Errorf("erro ao criar ProductDealers em batch: %w", err)
+			return nil, fmt.Errorf("erro ao consultar ProductDealers existentes: %w", err)
+		}
+
+		for rows.Next() {
+			var key productDealerKey
+			var status sql.NullInt64
+			if err := rows.Scan(&key.productID, &key.dealerID, &status); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("erro ao escanear ProductDealer: %w", err)
+			}
+			states[key] = status.Valid && status.Int64 != 0
+		}
+
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, fmt.Errorf("erro ao iterar ProductDealers: %w", err)
 		}
 	}
 
-	return nil
+	return states, nil
+}
+
+// isUniqueViolation verifica se o erro é uma violação de restrição única (ORA-00001)
+func isUniqueViolation(err error) bool {
+	var oraErr *network.OracleError
+	return errors.As(err, &oraErr) && oraErr.ErrCode == 1
 }
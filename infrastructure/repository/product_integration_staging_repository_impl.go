@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 
@@ -10,8 +11,8 @@ import (
 
 // ProductIntegrationStagingRepositoryImpl implementa o ProductIntegrationStagingRepository
 type ProductIntegrationStagingRepositoryImpl struct {
-	db                         *sql.DB
-	stmtGetByProductAndDealer  *sql.Stmt
+	db                        *sql.DB
+	stmtGetByProductAndDealer *sql.Stmt
 }
 
 // NewProductIntegrationStagingRepository cria uma nova instância do repositório
@@ -19,7 +20,7 @@ func NewProductIntegrationStagingRepository(db *sql.DB) repositories.ProductInte
 	repo := &ProductIntegrationStagingRepositoryImpl{
 		db: db,
 	}
-	
+
 	// Pré-compilar query de busca
 	var err error
 	repo.stmtGetByProductAndDealer, err = db.Prepare(`
@@ -30,14 +31,14 @@ func NewProductIntegrationStagingRepository(db *sql.DB) repositories.ProductInte
 	if err != nil {
 		panic(fmt.Sprintf("Erro ao preparar statement GetByProductAndDealer: %v", err))
 	}
-	
+
 	return repo
 }
 
 // GetByProductAndDealer busca um registro de integração por produto e revendedor
-func (r *ProductIntegrationStagingRepositoryImpl) GetByProductAndDealer(productID, dealerID int) (*entities.ProductIntegrationStaging, error) {
+func (r *ProductIntegrationStagingRepositoryImpl) GetByProductAndDealer(ctx context.Context, productID, dealerID int) (*entities.ProductIntegrationStaging, error) {
 	var staging entities.ProductIntegrationStaging
-	err := r.stmtGetByProductAndDealer.QueryRow(productID, dealerID).Scan(&staging.ProductID, &staging.DealerID)
+	err := r.stmtGetByProductAndDealer.QueryRowContext(ctx, productID, dealerID).Scan(&staging.ProductID, &staging.DealerID)
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -1,9 +1,11 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
+	"strings"
 	"sync/atomic"
 	"time"
 
@@ -30,7 +32,7 @@ func NewProductRepository(db *sql.DB) repositories.ProductRepository {
 	repo := &ProductRepositoryImpl{
 		db: db,
 	}
-	
+
 	// Pré-compilar query de busca por EAN
 	var err error
 	repo.stmtGetByEAN, err = db.Prepare(`
@@ -42,7 +44,7 @@ func NewProductRepository(db *sql.DB) repositories.ProductRepository {
 	if err != nil {
 		panic(fmt.Sprintf("Erro ao preparar statement GetByEAN: %v", err))
 	}
-	
+
 	// Pré-compilar stored procedure
 	repo.stmtSaveIntegrationStaging, err = db.Prepare(`
 		BEGIN
@@ -52,13 +54,13 @@ func NewProductRepository(db *sql.DB) repositories.ProductRepository {
 	if err != nil {
 		panic(fmt.Sprintf("Erro ao preparar statement SaveIntegrationStaging: %v", err))
 	}
-	
+
 	return repo
 }
 
 // GetByEAN busca produtos pelo código EAN (código de barras)
-func (r *ProductRepositoryImpl) GetByEAN(ean string) ([]entities.Product, error) {
-	rows, err := r.stmtGetByEAN.Query(ean)
+func (r *ProductRepositoryImpl) GetByEAN(ctx context.Context, ean string) ([]entities.Product, error) {
+	rows, err := r.stmtGetByEAN.QueryContext(ctx, ean)
 	if err != nil {
 		return nil, fmt.Errorf("erro ao buscar produto por EAN: %w", err)
 	}
@@ -80,8 +82,63 @@ func (r *ProductRepositoryImpl) GetByEAN(ean string) ([]entities.Product, error)
 	return products, nil
 }
 
+// GetByEANs busca os produtos de vários EANs em consultas com lista IN, em blocos
+// (o Oracle limita a lista IN a 1000 expressões)
+func (r *ProductRepositoryImpl) GetByEANs(ctx context.Context, eans []string) (map[string][]entities.Product, error) {
+	const chunkSize = 500
+
+	products := make(map[string][]entities.Product, len(eans))
+
+	for i := 0; i < len(eans); i += chunkSize {
+		end := i + chunkSize
+		if end > len(eans) {
+			end = len(eans)
+		}
+		chunk := eans[i:end]
+
+		var query strings.Builder
+		query.WriteString(`
+			SELECT DISTINCT p.IDPRODUTO, e.CODIGOBARRAS
+			FROM Produto p
+			INNER JOIN EmbalagemProduto e ON p.IDPRODUTO = e.IDPRODUTO
+			WHERE e.CODIGOBARRAS IN (`)
+
+		args := make([]interface{}, len(chunk))
+		for idx, ean := range chunk {
+			if idx > 0 {
+				query.WriteString(", ")
+			}
+			query.WriteString(fmt.Sprintf(":%d", idx+1))
+			args[idx] = ean
+		}
+		query.WriteString(")")
+
+		rows, err := r.db.QueryContext(ctx, query.String(), args...)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao buscar produtos por EAN em lote: %w", err)
+		}
+
+		for rows.Next() {
+			var product entities.Product
+			if err := rows.Scan(&product.ID, &product.EAN); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("erro ao escanear produto: %w", err)
+			}
+			products[product.EAN] = append(products[product.EAN], product)
+		}
+
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, fmt.Errorf("erro ao iterar produtos: %w", err)
+		}
+	}
+
+	return products, nil
+}
+
 // SaveIntegrationStaging grava a integração do produto no staging chamando a stored procedure
-func (r *ProductRepositoryImpl) SaveIntegrationStaging(dealerID, productID int) error {
+func (r *ProductRepositoryImpl) SaveIntegrationStaging(ctx context.Context, dealerID, productID int) error {
 	start := time.Now()
 
 	// Incrementa contador de chamadas
@@ -98,7 +155,7 @@ func (r *ProductRepositoryImpl) SaveIntegrationStaging(dealerID, productID int)
 	}
 
 	// Chama a stored procedure usando prepared statement
-	_, err := r.stmtSaveIntegrationStaging.Exec(dealerID, productID)
+	_, err := r.stmtSaveIntegrationStaging.ExecContext(ctx, dealerID, productID)
 
 	// Registra tempo de execução
 	elapsed := time.Since(start).Nanoseconds()
@@ -0,0 +1,54 @@
+package usecase
+
+import (
+	"fmt"
+	"sort"
+
+	"github.thiagohmm.com.br/cargaparcial/domain/entities"
+)
+
+// AmbiguityPolicy define o tratamento de um EAN associado a mais de um produto
+type AmbiguityPolicy string
+
+const (
+	// AmbiguityFail reporta o par como falha, listando os produtos candidatos
+	AmbiguityFail AmbiguityPolicy = "fail"
+	// AmbiguityLinkAll vincula todos os produtos candidatos ao revendedor
+	AmbiguityLinkAll AmbiguityPolicy = "all"
+	// AmbiguityPreferRecent vincula apenas o produto mais recente (maior IdProduto)
+	AmbiguityPreferRecent AmbiguityPolicy = "recent"
+)
+
+// ParseAmbiguityPolicy converte o valor informado na configuração em uma AmbiguityPolicy
+func ParseAmbiguityPolicy(value string) (AmbiguityPolicy, error) {
+	switch policy := AmbiguityPolicy(value); policy {
+	case AmbiguityFail, AmbiguityLinkAll, AmbiguityPreferRecent:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("política de EAN ambíguo inválida: %q (use fail, all ou recent)", value)
+	}
+}
+
+// selectProducts aplica a política aos produtos de um EAN.
+// Retorna os IDs a vincular (vazio quando o par deve falhar) e, se o EAN for
+// ambíguo, todos os IDs candidatos em ordem crescente.
+func (p AmbiguityPolicy) selectProducts(products []entities.Product) (selected, candidates []int) {
+	if len(products) == 1 {
+		return []int{products[0].ID}, nil
+	}
+
+	candidates = make([]int, len(products))
+	for i, product := range products {
+		candidates[i] = product.ID
+	}
+	sort.Ints(candidates)
+
+	switch p {
+	case AmbiguityLinkAll:
+		return candidates, candidates
+	case AmbiguityPreferRecent:
+		return candidates[len(candidates)-1:], candidates
+	default:
+		return nil, candidates
+	}
+}
@@ -0,0 +1,84 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.thiagohmm.com.br/cargaparcial/usecase/dto"
+)
+
+// CheckpointJournal persiste o resultado de cada par processado, permitindo
+// retomar uma execução interrompida sem reprocessar os pares já concluídos
+type CheckpointJournal interface {
+	// Header retorna o cabeçalho do journal (nil em um journal novo ou gravado antes do cabeçalho)
+	Header() *dto.CheckpointHeader
+	// WriteHeader grava o cabeçalho de um journal novo
+	WriteHeader(header dto.CheckpointHeader) error
+	// Entries retorna os pares já concluídos em execuções anteriores
+	Entries() []dto.CheckpointEntry
+	// Record registra o resultado de um par concluído
+	Record(entry dto.CheckpointEntry) error
+}
+
+// pairKey identifica um par IBM/EAN
+type pairKey struct {
+	ibm string
+	ean string
+}
+
+// checkpointHeader monta o cabeçalho do journal para o modo da execução
+func (uc *ProcessProductsUseCase) checkpointHeader(mode OperationMode) dto.CheckpointHeader {
+	header := dto.CheckpointHeader{Mode: string(mode)}
+	if mode != ModeLink {
+		header.UnlinkStrategy = string(uc.unlinkStrategy)
+	}
+	return header
+}
+
+// prepareJournal grava o cabeçalho de um journal novo ou confere se o journal
+// retomado foi gravado com o mesmo modo e a mesma estratégia de remoção
+func prepareJournal(journal CheckpointJournal, expected dto.CheckpointHeader) error {
+	header := journal.Header()
+	switch {
+	case header != nil && *header != expected:
+		return fmt.Errorf("journal gravado no modo %s (estratégia %q) não pode ser retomado no modo %s (estratégia %q)",
+			header.Mode, header.UnlinkStrategy, expected.Mode, expected.UnlinkStrategy)
+	case header != nil:
+		return nil
+	case len(journal.Entries()) > 0:
+		// Journal anterior ao cabeçalho: não há como conferir o modo
+		log.Printf("⚠️  Journal sem cabeçalho: confira se a execução original usou o modo %s", expected.Mode)
+		return nil
+	}
+	return journal.WriteHeader(expected)
+}
+
+// completedPairs indexa as entradas do journal pelo par IBM/EAN. Falhas por erro
+// transitório do banco (de journals antigos) não contam como concluídas.
+func completedPairs(journal CheckpointJournal) map[pairKey]dto.ProductResultDTO {
+	completed := make(map[pairKey]dto.ProductResultDTO)
+	if journal == nil {
+		return completed
+	}
+
+	for _, entry := range journal.Entries() {
+		if !journaled(entry.Result) {
+			continue
+		}
+		completed[pairKey{ibm: entry.IBM, ean: entry.EAN}] = entry.Result
+	}
+	return completed
+}
+
+// journaled indica se o resultado é definitivo e pode ser registrado no journal:
+// pares não processados e falhas por erro transitório do banco são refeitos na retomada
+func journaled(result dto.ProductResultDTO) bool {
+	switch {
+	case result.Status == statusNotProcessed:
+		return false
+	case result.Status != "ok" && result.DBError != "":
+		return !isTransientError(errors.New(result.DBError))
+	}
+	return true
+}
@@ -0,0 +1,106 @@
+package usecase
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.thiagohmm.com.br/cargaparcial/usecase/dto"
+)
+
+// oracleCodePattern encontra o código Oracle na mensagem de erro do driver (ex.: "ORA-03113: end-of-file on communication channel").
+// O go-ora formata sem zeros à esquerda os códigos que não traduz (ex.: "ORA-3113").
+var oracleCodePattern = regexp.MustCompile(`ORA-(\d+)`)
+
+// transientOracleCodes são os erros Oracle de conexão, rede ou concorrência que
+// podem não se repetir em uma nova tentativa
+var transientOracleCodes = map[string]bool{
+	"ORA-00028": true, // sessão encerrada pelo DBA
+	"ORA-00054": true, // recurso ocupado (lock)
+	"ORA-00060": true, // deadlock
+	"ORA-01012": true, // sessão sem login
+	"ORA-01033": true, // banco iniciando ou encerrando
+	"ORA-01034": true, // Oracle indisponível
+	"ORA-01089": true, // shutdown imediato em andamento
+	"ORA-03113": true, // fim de arquivo no canal de comunicação
+	"ORA-03114": true, // sem conexão com o Oracle
+	"ORA-03135": true, // conexão perdida
+	"ORA-12170": true, // timeout de conexão
+	"ORA-12516": true, // listener sem handler disponível
+	"ORA-12520": true, // listener sem handler para o tipo de servidor
+	"ORA-12528": true, // instância bloqueando novas conexões
+	"ORA-12537": true, // conexão TNS fechada
+	"ORA-12541": true, // sem listener
+	"ORA-12543": true, // destino inalcançável
+	"ORA-12571": true, // falha ao gravar pacote TNS
+}
+
+// transientMessages identificam, pela mensagem, erros de rede que chegam sem tipo (ex.: formatados com %v)
+var transientMessages = []string{"connection reset", "broken pipe", "i/o timeout", "connection refused", "bad connection"}
+
+// oracleErrorCode extrai o código Oracle do erro (vazio quando a mensagem não traz um)
+func oracleErrorCode(err error) string {
+	if err == nil {
+		return ""
+	}
+	match := oracleCodePattern.FindStringSubmatch(err.Error())
+	if match == nil {
+		return ""
+	}
+	code, convErr := strconv.Atoi(match[1])
+	if convErr != nil {
+		return match[0]
+	}
+	return fmt.Sprintf("ORA-%05d", code)
+}
+
+// isTransientError indica se o erro do banco é transitório (queda de conexão,
+// timeout de rede, deadlock) e a operação pode ser repetida. Cancelamentos não são,
+// nem erros de rede permanentes (ex.: host inexistente): de um net.Error só o timeout conta.
+func isTransientError(err error) bool {
+	switch {
+	case err == nil,
+		errors.Is(err, context.Canceled),
+		errors.Is(err, context.DeadlineExceeded):
+		return false
+	case errors.Is(err, driver.ErrBadConn),
+		errors.Is(err, io.EOF),
+		errors.Is(err, io.ErrUnexpectedEOF),
+		errors.Is(err, syscall.ECONNRESET),
+		errors.Is(err, syscall.ECONNREFUSED),
+		errors.Is(err, syscall.EPIPE):
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	if transientOracleCodes[oracleErrorCode(err)] {
+		return true
+	}
+
+	message := strings.ToLower(err.Error())
+	for _, fragment := range transientMessages {
+		if strings.Contains(message, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+// withDBError registra no resultado o erro do banco que causou a falha
+func withDBError(result dto.ProductResultDTO, err error) dto.ProductResultDTO {
+	if err != nil {
+		result.DBError = err.Error()
+		result.DBCode = oracleErrorCode(err)
+	}
+	return result
+}
@@ -0,0 +1,63 @@
+package usecase
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+)
+
+func TestIsTransientError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil, want: false},
+		{name: "cancelamento", err: context.Canceled, want: false},
+		{name: "prazo esgotado", err: fmt.Errorf("staging: %w", context.DeadlineExceeded), want: false},
+		{name: "conexão inválida", err: driver.ErrBadConn, want: true},
+		{name: "EOF", err: fmt.Errorf("leitura: %w", io.EOF), want: true},
+		{name: "conexão reiniciada", err: &net.OpError{Op: "read", Err: os.NewSyscallError("read", syscall.ECONNRESET)}, want: true},
+		{name: "timeout de rede", err: &net.DNSError{Err: "timeout", IsTimeout: true}, want: true},
+		{name: "host inexistente", err: &net.DNSError{Err: "no such host", Name: "oracle.invalid", IsNotFound: true}, want: false},
+		{name: "ORA-03113", err: errors.New("ORA-03113: end-of-file on communication channel"), want: true},
+		{name: "ORA sem zeros à esquerda", err: errors.New("ORA-60: deadlock detected"), want: true},
+		{name: "ORA-00001", err: errors.New("ORA-00001: unique constraint violated"), want: false},
+		{name: "erro de negócio da procedure", err: errors.New("ORA-20001: produto bloqueado"), want: false},
+		{name: "mensagem de rede sem tipo", err: errors.New("write tcp 10.0.0.1:1521: broken pipe"), want: true},
+		{name: "erro qualquer", err: errors.New("valor inválido"), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isTransientError(tt.err); got != tt.want {
+				t.Errorf("isTransientError(%v) = %v, esperado %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOracleErrorCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{nil, ""},
+		{errors.New("ORA-03113: end-of-file on communication channel"), "ORA-03113"},
+		{errors.New("ORA-3113"), "ORA-03113"},
+		{fmt.Errorf("staging: %w", errors.New("ORA-20001: produto bloqueado")), "ORA-20001"},
+		{errors.New("connection reset by peer"), ""},
+	}
+
+	for _, tt := range tests {
+		if got := oracleErrorCode(tt.err); got != tt.want {
+			t.Errorf("oracleErrorCode(%v) = %q, esperado %q", tt.err, got, tt.want)
+		}
+	}
+}
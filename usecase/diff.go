@@ -0,0 +1,123 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+
+	"github.thiagohmm.com.br/cargaparcial/usecase/dto"
+)
+
+// DiffOptions contém as opções da comparação entre a entrada e o banco
+type DiffOptions struct {
+	// IncludeAbsent lista também os produtos vinculados às lojas que não estão na entrada
+	IncludeAbsent bool
+}
+
+// Diff compara os pares da entrada com as relações ativas de cada loja e retorna,
+// por loja, os EANs já vinculados, a vincular e desconhecidos. Apenas consultas
+// são feitas: nenhuma relação, staging ou mensagem é gravada.
+func (uc *ProcessProductsUseCase) Diff(ctx context.Context, input dto.ProcessProductsInput, opts DiffOptions) (*dto.DiffOutput, error) {
+	input = normalizeIBMs(input, uc.ibmOptions)
+
+	dealerMap, missingIBMs, cancelledIBMs, err := uc.preloadDealers(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	if len(cancelledIBMs) > 0 {
+		return nil, ctx.Err()
+	}
+
+	eans, invalidEANs := distinctEANs(input, dealerMap, nil, uc.gtinOptions)
+	productsByEAN, err := uc.productRepo.GetByEANs(ctx, eans)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao resolver EANs: %w", err)
+	}
+
+	dealerIDs := make([]int, 0, len(dealerMap))
+	ibmCodes := make([]string, 0, len(dealerMap))
+	for ibmCode, dealer := range dealerMap {
+		dealerIDs = append(dealerIDs, dealer.ID)
+		ibmCodes = append(ibmCodes, ibmCode)
+	}
+	sort.Ints(dealerIDs)
+	sort.Strings(ibmCodes)
+
+	activeByDealer, err := uc.productDealerRepo.ListActiveProducts(ctx, dealerIDs)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar relações ativas: %w", err)
+	}
+
+	output := &dto.DiffOutput{
+		Stores:        make([]dto.StoreDiff, 0, len(ibmCodes)),
+		UnknownStores: missingIBMs,
+		InvalidEANs:   invalidEANs,
+	}
+
+	for _, ibmCode := range ibmCodes {
+		dealer := dealerMap[ibmCode]
+
+		active := make(map[int]bool, len(activeByDealer[dealer.ID]))
+		for _, product := range activeByDealer[dealer.ID] {
+			active[product.ID] = true
+		}
+
+		store := dto.StoreDiff{
+			IBM:      ibmCode,
+			DealerID: dealer.ID,
+			Linked:   []string{},
+			ToLink:   []string{},
+			Unknown:  []string{},
+		}
+		inFile := make(map[int]bool)
+		seen := make(map[string]bool)
+
+		for _, productCode := range productsForIBM(input, ibmCode) {
+			if seen[productCode] {
+				continue
+			}
+			seen[productCode] = true
+
+			ean, err := uc.gtinOptions.Normalize(productCode)
+			if err != nil || len(productsByEAN[ean]) == 0 {
+				store.Unknown = append(store.Unknown, productCode)
+				continue
+			}
+
+			products := productsByEAN[ean]
+			for _, product := range products {
+				inFile[product.ID] = true
+			}
+
+			selected, _ := uc.ambiguityPolicy.selectProducts(products)
+			switch {
+			case len(selected) == 0:
+				store.Ambiguous = append(store.Ambiguous, productCode)
+			case allActive(active, selected):
+				store.Linked = append(store.Linked, productCode)
+			default:
+				store.ToLink = append(store.ToLink, productCode)
+			}
+		}
+
+		if opts.IncludeAbsent {
+			for _, product := range activeByDealer[dealer.ID] {
+				if !inFile[product.ID] {
+					store.Absent = append(store.Absent, dto.LinkedProduct{ProductID: product.ID, EAN: product.EAN})
+				}
+			}
+			sort.Slice(store.Absent, func(i, j int) bool { return store.Absent[i].ProductID < store.Absent[j].ProductID })
+		}
+
+		sort.Strings(store.Linked)
+		sort.Strings(store.ToLink)
+		sort.Strings(store.Unknown)
+		sort.Strings(store.Ambiguous)
+
+		log.Printf("🔍 IBM %s: %d já vinculados, %d a vincular, %d desconhecidos", ibmCode, len(store.Linked), len(store.ToLink), len(store.Unknown))
+		output.Stores = append(output.Stores, store)
+	}
+
+	return output, nil
+}
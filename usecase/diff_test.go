@@ -0,0 +1,76 @@
+package usecase
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.thiagohmm.com.br/cargaparcial/domain/entities"
+	"github.thiagohmm.com.br/cargaparcial/usecase/dto"
+)
+
+func TestDiff(t *testing.T) {
+	f := newTestFixture(
+		map[string]int{"1": 1, "2": 2},
+		map[string]int{testEAN(1): 100, testEAN(2): 200, testEAN(5): 500},
+	)
+	f.products.products[testEAN(4)] = []entities.Product{{ID: 400, EAN: testEAN(4)}, {ID: 401, EAN: testEAN(4)}}
+	f.productDealer.relations = map[[2]int]bool{
+		{100, 1}: true,
+		{200, 1}: false, // inativa: a carga reativaria
+		{500, 1}: true,  // vinculado à loja, fora do arquivo
+		{100, 2}: true,
+	}
+
+	input := pairsInput(map[string][]string{
+		"1": {testEAN(1), testEAN(2), testEAN(3), testEAN(4), "124", testEAN(1)},
+		"2": {testEAN(1)},
+		"9": {testEAN(1)},
+	})
+
+	output, err := f.uc.Diff(context.Background(), input, DiffOptions{IncludeAbsent: true})
+	if err != nil {
+		t.Fatalf("Diff: %v", err)
+	}
+
+	if !slices.Equal(output.UnknownStores, []string{"9"}) {
+		t.Errorf("UnknownStores = %v, esperado [9]", output.UnknownStores)
+	}
+	if !slices.Equal(output.InvalidEANs, []string{"124"}) {
+		t.Errorf("InvalidEANs = %v, esperado [124]", output.InvalidEANs)
+	}
+	if len(output.Stores) != 2 {
+		t.Fatalf("%d lojas, esperado 2", len(output.Stores))
+	}
+
+	store := output.Stores[0]
+	if store.IBM != "1" || store.DealerID != 1 {
+		t.Fatalf("primeira loja %s/%d, esperado 1/1", store.IBM, store.DealerID)
+	}
+	checks := []struct {
+		name      string
+		got, want []string
+	}{
+		{"Linked", store.Linked, []string{testEAN(1)}},
+		{"ToLink", store.ToLink, []string{testEAN(2)}},
+		{"Unknown", store.Unknown, []string{"124", testEAN(3)}},
+		{"Ambiguous", store.Ambiguous, []string{testEAN(4)}},
+	}
+	for _, check := range checks {
+		if !slices.Equal(check.got, check.want) {
+			t.Errorf("%s = %v, esperado %v", check.name, check.got, check.want)
+		}
+	}
+	if !slices.Equal(store.Absent, []dto.LinkedProduct{{ProductID: 500}}) {
+		t.Errorf("Absent = %v, esperado [{500}]", store.Absent)
+	}
+
+	if other := output.Stores[1]; !slices.Equal(other.Linked, []string{testEAN(1)}) || len(other.ToLink) != 0 || len(other.Absent) != 0 {
+		t.Errorf("segunda loja %+v, esperado apenas %s vinculado", other, testEAN(1))
+	}
+
+	// Diff só consulta
+	if f.products.calls.Load() != 0 || f.queue.sent.Load() != 0 {
+		t.Errorf("Diff gravou no banco: %d chamadas da procedure, %d mensagens", f.products.calls.Load(), f.queue.sent.Load())
+	}
+}
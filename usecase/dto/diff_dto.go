@@ -0,0 +1,25 @@
+package dto
+
+// DiffOutput representa o que uma carga mudaria em cada loja, sem gravar nada
+type DiffOutput struct {
+	Stores        []StoreDiff `json:"lojas"`
+	UnknownStores []string    `json:"ibmsNaoEncontrados,omitempty"` // IBMs sem revendedor cadastrado
+	InvalidEANs   []string    `json:"eansInvalidos,omitempty"`      // Códigos da entrada reprovados na validação de GTIN
+}
+
+// StoreDiff lista os EANs de uma loja conforme o estado atual das relações no banco
+type StoreDiff struct {
+	IBM       string          `json:"IBM"`
+	DealerID  int             `json:"IdRevendedor"`
+	Linked    []string        `json:"jaVinculados"`                // EANs com relação ativa
+	ToLink    []string        `json:"aVincular"`                   // EANs sem relação ativa, que a carga vincularia
+	Unknown   []string        `json:"desconhecidos"`               // EANs inválidos ou sem produto cadastrado
+	Ambiguous []string        `json:"ambiguos,omitempty"`          // EANs com mais de um produto que a política não vincula
+	Absent    []LinkedProduct `json:"ausentesNoArquivo,omitempty"` // Produtos vinculados à loja que não estão no arquivo
+}
+
+// LinkedProduct identifica um produto com relação ativa em uma loja
+type LinkedProduct struct {
+	ProductID int    `json:"IdProduto"`
+	EAN       string `json:"EAN,omitempty"`
+}
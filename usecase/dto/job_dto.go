@@ -0,0 +1,22 @@
+package dto
+
+import "time"
+
+// JobSubmittedDTO representa a resposta da submissão de um job assíncrono
+type JobSubmittedDTO struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+}
+
+// JobStatusDTO representa o estado atual de um job assíncrono
+type JobStatusDTO struct {
+	ID         string     `json:"id"`
+	Status     string     `json:"status"`
+	Processed  int        `json:"processados"`
+	Total      int        `json:"total"`
+	ETASeconds *float64   `json:"etaSegundos,omitempty"`
+	CreatedAt  time.Time  `json:"criadoEm"`
+	StartedAt  *time.Time `json:"iniciadoEm,omitempty"`
+	FinishedAt *time.Time `json:"finalizadoEm,omitempty"`
+	Error      string     `json:"erro,omitempty"`
+}
@@ -2,22 +2,118 @@ package dto
 
 // ProcessProductsInput representa os dados de entrada para processar produtos
 type ProcessProductsInput struct {
-	IBMCodes      []string            `json:"IBM"`
-	ProductCodes  []string            `json:"codigo"`
-	IBMToProducts map[string][]string `json:"-"` // Relacionamento IBM -> Produtos (não vem do JSON)
+	IBMCodes      []string               `json:"IBM"`
+	ProductCodes  []string               `json:"codigo"`
+	IBMToProducts map[string][]string    `json:"-"` // Relacionamento IBM -> Produtos (não vem do JSON)
+	SourceRows    map[[2]string][]string `json:"-"` // Linhas de origem de cada par IBM/EAN no arquivo (ex.: "Plan1!12")
 }
 
 // ProductResultDTO representa o resultado do processamento de um produto
 type ProductResultDTO struct {
-	DealerID  *int   `json:"IdRevendedor"`
-	ProductID *int   `json:"IdProduto"`
-	EAN       string `json:"EAN,omitempty"`
-	Status    string `json:"Status"`
-	Reason    string `json:"Motivo,omitempty"`
+	DealerID       *int     `json:"IdRevendedor"`
+	ProductID      *int     `json:"IdProduto"`
+	IBM            string   `json:"IBM,omitempty"`
+	EAN            string   `json:"EAN,omitempty"`
+	Status         string   `json:"Status"`
+	Reason         string   `json:"Motivo,omitempty"`
+	ReasonCode     string   `json:"CodigoMotivo,omitempty"`         // Código estável do motivo (ex.: EAN_NOT_FOUND)
+	SourceRows     []string `json:"LinhasOrigem,omitempty"`         // Linhas do arquivo de entrada em que o par aparece
+	DBError        string   `json:"ErroBanco,omitempty"`            // Erro do banco que causou a falha
+	DBCode         string   `json:"CodigoErroBanco,omitempty"`      // Código Oracle do erro (ex.: ORA-03113)
+	Attempts       int      `json:"Tentativas,omitempty"`           // Tentativas da operação de banco que mais precisou repetir (omitido sem novas tentativas)
+	Candidates     []int    `json:"ProdutosCandidatos,omitempty"`   // Produtos de um EAN associado a mais de um produto
+	IBMSuggestions []string `json:"SugestoesIBM,omitempty"`         // IBMs cadastrados parecidos com um IBM sem revendedor
+	Relation       string   `json:"Relacao,omitempty"`              // Resultado da gravação de ProdutoRevendedor (ex.: criada, desativada)
+	Planned        []string `json:"AcoesPlanejadas,omitempty"`      // Ações que seriam executadas (somente em simulação)
+	SyncRemoval    bool     `json:"RemocaoSincronizacao,omitempty"` // Relação fora da entrada removida pela sincronização: o EAN é só o menor código de barras do produto
 }
 
+// Códigos estáveis do motivo de um resultado (CodigoMotivo). Ao contrário do texto
+// em Motivo, não mudam entre versões e podem ser usados em filtros e integrações.
+const (
+	ReasonInvalidEAN          = "INVALID_EAN"
+	ReasonEANNotFound         = "EAN_NOT_FOUND"
+	ReasonAmbiguousEAN        = "AMBIGUOUS_EAN"
+	ReasonDealerNotFound      = "DEALER_NOT_FOUND"
+	ReasonRelationCheckError  = "RELATION_CHECK_ERROR"
+	ReasonRelationRemoveError = "RELATION_REMOVE_ERROR"
+	ReasonRelationNotFound    = "RELATION_NOT_FOUND"
+	ReasonStagingSPError      = "STAGING_SP_ERROR"
+	ReasonStagingCheckError   = "STAGING_CHECK_ERROR"
+	ReasonStagingNotVisible   = "STAGING_NOT_VISIBLE"
+	ReasonCancelled           = "CANCELLED"
+)
+
+// Resultado da gravação da relação ProdutoRevendedor de um par
+const (
+	RelationCreated         = "criada"
+	RelationReactivated     = "reativada"
+	RelationAlreadyActive   = "ja_ativa"
+	RelationDeactivated     = "desativada"
+	RelationDeleted         = "excluida"
+	RelationAlreadyInactive = "ja_inativa"
+	RelationNotFound        = "nao_encontrada"
+)
+
+// Modos de operação gravados em ProcessProductsOutput.Mode e no cabeçalho do journal
+const (
+	ModeLink   = "link"
+	ModeUnlink = "unlink"
+	ModeSync   = "sync"
+)
+
+// Ações planejadas de um par em uma simulação (dry-run)
+const (
+	ActionCreateRelation     = "criar_relacao"
+	ActionReactivateRelation = "reativar_relacao"
+	ActionAlreadyLinked      = "ja_vinculado"
+	ActionDeactivateRelation = "desativar_relacao"
+	ActionDeleteRelation     = "excluir_relacao"
+	ActionAlreadyUnlinked    = "ja_desvinculado"
+	ActionStage              = "gravar_staging"
+)
+
+// OutputSchemaVersion é a versão do formato de ProcessProductsOutput. A versão 2
+// acrescenta CodigoMotivo, LinhasOrigem, ErroBanco e CodigoErroBanco aos resultados,
+// mantendo os campos da versão 1 (arrayOk, arrayFail, Motivo...).
+const OutputSchemaVersion = 2
+
 // ProcessProductsOutput representa o resultado do processamento
 type ProcessProductsOutput struct {
-	SuccessList []ProductResultDTO `json:"arrayOk"`
-	FailureList []ProductResultDTO `json:"arrayFail"`
+	SchemaVersion    int                `json:"versao"`
+	SuccessList      []ProductResultDTO `json:"arrayOk"`
+	FailureList      []ProductResultDTO `json:"arrayFail"`
+	NotProcessedList []ProductResultDTO `json:"arrayNaoProcessado,omitempty"` // Pares não processados por cancelamento
+	Totals           ResultTotals       `json:"totais"`                       // Quantidade de resultados por status (preenchida mesmo sem as listas)
+	Cancelled        bool               `json:"cancelado,omitempty"`
+	Mode             string             `json:"modo,omitempty"`               // Modo de operação: link, unlink ou sync
+	DryRun           bool               `json:"simulacao,omitempty"`          // Nada foi gravado: os resultados trazem as ações planejadas
+	UnresolvedEANs   []string           `json:"eansNaoEncontrados,omitempty"` // EANs distintos sem produto cadastrado
+	InvalidEANs      []string           `json:"eansInvalidos,omitempty"`      // Códigos da entrada reprovados na validação de GTIN
+}
+
+// ResultTotals conta os resultados de uma execução por status
+type ResultTotals struct {
+	Ok           int `json:"ok"`
+	Fail         int `json:"falhas"`
+	NotProcessed int `json:"naoProcessados"`
+}
+
+// Total retorna o número de pares com resultado
+func (t ResultTotals) Total() int {
+	return t.Ok + t.Fail + t.NotProcessed
+}
+
+// CheckpointHeader identifica, na primeira linha do journal, a execução que o gravou:
+// só é possível retomar com o mesmo modo e a mesma estratégia de remoção
+type CheckpointHeader struct {
+	Mode           string `json:"modo"`
+	UnlinkStrategy string `json:"estrategiaRemocao,omitempty"` // Somente nos modos unlink e sync
+}
+
+// CheckpointEntry representa o resultado de um par IBM/EAN registrado no journal de checkpoint
+type CheckpointEntry struct {
+	IBM    string           `json:"IBM"`
+	EAN    string           `json:"EAN"`
+	Result ProductResultDTO `json:"resultado"`
 }
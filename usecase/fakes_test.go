@@ -0,0 +1,292 @@
+package usecase
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.thiagohmm.com.br/cargaparcial/domain/entities"
+	"github.thiagohmm.com.br/cargaparcial/usecase/dto"
+)
+
+// fakeDealerRepo é um cadastro de revendedores em memória
+type fakeDealerRepo struct {
+	dealers map[string]*entities.Dealer
+}
+
+func (r *fakeDealerRepo) GetByIBM(ctx context.Context, ibm string) (*entities.Dealer, error) {
+	return r.dealers[ibm], nil
+}
+
+func (r *fakeDealerRepo) GetByIBMs(ctx context.Context, ibms []string) (map[string]*entities.Dealer, error) {
+	found := make(map[string]*entities.Dealer)
+	for _, ibm := range ibms {
+		if dealer, ok := r.dealers[ibm]; ok {
+			found[ibm] = dealer
+		}
+	}
+	return found, nil
+}
+
+func (r *fakeDealerRepo) ListIBMs(ctx context.Context) ([]string, error) {
+	ibms := make([]string, 0, len(r.dealers))
+	for ibm := range r.dealers {
+		ibms = append(ibms, ibm)
+	}
+	sort.Strings(ibms)
+	return ibms, nil
+}
+
+// fakeProductRepo resolve EANs em memória e registra as chamadas da procedure de staging.
+// onStage, quando informado, é chamado antes de cada gravação e pode simular uma falha;
+// afterStage é chamado depois da gravação e simula a queda da conexão após o commit.
+type fakeProductRepo struct {
+	products   map[string][]entities.Product
+	onStage    func(ctx context.Context, dealerID, productID int) error
+	afterStage func(ctx context.Context, dealerID, productID int) error
+
+	mu     sync.Mutex
+	staged map[[2]int]bool
+	calls  atomic.Int64
+}
+
+func (r *fakeProductRepo) GetByEAN(ctx context.Context, ean string) ([]entities.Product, error) {
+	return r.products[ean], nil
+}
+
+func (r *fakeProductRepo) GetByEANs(ctx context.Context, eans []string) (map[string][]entities.Product, error) {
+	found := make(map[string][]entities.Product)
+	for _, ean := range eans {
+		if products, ok := r.products[ean]; ok {
+			found[ean] = products
+		}
+	}
+	return found, nil
+}
+
+func (r *fakeProductRepo) SaveIntegrationStaging(ctx context.Context, dealerID, productID int) error {
+	r.calls.Add(1)
+	if r.onStage != nil {
+		if err := r.onStage(ctx, dealerID, productID); err != nil {
+			return err
+		}
+	}
+
+	r.mu.Lock()
+	if r.staged == nil {
+		r.staged = make(map[[2]int]bool)
+	}
+	r.staged[[2]int{dealerID, productID}] = true
+	r.mu.Unlock()
+
+	if r.afterStage != nil {
+		return r.afterStage(ctx, dealerID, productID)
+	}
+	return nil
+}
+
+func (r *fakeProductRepo) isStaged(dealerID, productID int) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.staged[[2]int{dealerID, productID}]
+}
+
+// fakeStagingRepo confirma os registros gravados por fakeProductRepo
+type fakeStagingRepo struct {
+	products *fakeProductRepo
+}
+
+func (r *fakeStagingRepo) GetByProductAndDealer(ctx context.Context, productID, dealerID int) (*entities.ProductIntegrationStaging, error) {
+	if !r.products.isStaged(dealerID, productID) {
+		return nil, nil
+	}
+	return &entities.ProductIntegrationStaging{ProductID: productID, DealerID: dealerID}, nil
+}
+
+// fakeProductDealerRepo guarda as relações em memória (true = ativa). Com dropLast,
+// EnsureBatch omite o resultado da última relação do batch.
+type fakeProductDealerRepo struct {
+	mu        sync.Mutex
+	relations map[[2]int]bool
+	dropLast  bool
+}
+
+func (r *fakeProductDealerRepo) state(pd *entities.ProductDealer) (active, exists bool) {
+	active, exists = r.relations[[2]int{pd.ProductID, pd.DealerID}]
+	return active, exists
+}
+
+func (r *fakeProductDealerRepo) EnsureBatch(ctx context.Context, productDealers []*entities.ProductDealer) ([]entities.RelationStatus, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.relations == nil {
+		r.relations = make(map[[2]int]bool)
+	}
+
+	statuses := r.classify(productDealers)
+	for _, pd := range productDealers {
+		r.relations[[2]int{pd.ProductID, pd.DealerID}] = true
+	}
+	if r.dropLast && len(statuses) > 0 {
+		statuses = statuses[:len(statuses)-1]
+	}
+	return statuses, nil
+}
+
+func (r *fakeProductDealerRepo) PlanBatch(ctx context.Context, productDealers []*entities.ProductDealer) ([]entities.RelationStatus, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.classify(productDealers), nil
+}
+
+func (r *fakeProductDealerRepo) classify(productDealers []*entities.ProductDealer) []entities.RelationStatus {
+	statuses := make([]entities.RelationStatus, len(productDealers))
+	for i, pd := range productDealers {
+		active, exists := r.state(pd)
+		switch {
+		case !exists:
+			statuses[i] = entities.RelationCreated
+		case !active:
+			statuses[i] = entities.RelationReactivated
+		default:
+			statuses[i] = entities.RelationAlreadyActive
+		}
+	}
+	return statuses
+}
+
+func (r *fakeProductDealerRepo) DeactivateBatch(ctx context.Context, productDealers []*entities.ProductDealer) ([]entities.RelationStatus, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	statuses := make([]entities.RelationStatus, len(productDealers))
+	for i, pd := range productDealers {
+		active, exists := r.state(pd)
+		switch {
+		case !exists:
+			statuses[i] = entities.RelationNotFound
+		case !active:
+			statuses[i] = entities.RelationAlreadyInactive
+		default:
+			statuses[i] = entities.RelationDeactivated
+			r.relations[[2]int{pd.ProductID, pd.DealerID}] = false
+		}
+	}
+	return statuses, nil
+}
+
+func (r *fakeProductDealerRepo) DeleteBatch(ctx context.Context, productDealers []*entities.ProductDealer) ([]entities.RelationStatus, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	statuses := make([]entities.RelationStatus, len(productDealers))
+	for i, pd := range productDealers {
+		if _, exists := r.state(pd); !exists {
+			statuses[i] = entities.RelationNotFound
+			continue
+		}
+		statuses[i] = entities.RelationDeleted
+		delete(r.relations, [2]int{pd.ProductID, pd.DealerID})
+	}
+	return statuses, nil
+}
+
+func (r *fakeProductDealerRepo) ListActiveProducts(ctx context.Context, dealerIDs []int) (map[int][]entities.Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	wanted := make(map[int]bool, len(dealerIDs))
+	for _, id := range dealerIDs {
+		wanted[id] = true
+	}
+	products := make(map[int][]entities.Product)
+	for key, active := range r.relations {
+		if active && wanted[key[1]] {
+			products[key[1]] = append(products[key[1]], entities.Product{ID: key[0]})
+		}
+	}
+	return products, nil
+}
+
+// fakeQueue conta as mensagens publicadas
+type fakeQueue struct {
+	sent atomic.Int64
+}
+
+func (q *fakeQueue) Send(message string) error {
+	q.sent.Add(1)
+	return nil
+}
+
+// fakeJournal é um journal de checkpoint em memória
+type fakeJournal struct {
+	mu      sync.Mutex
+	header  *dto.CheckpointHeader
+	entries []dto.CheckpointEntry
+	written []dto.CheckpointEntry
+}
+
+func (j *fakeJournal) Header() *dto.CheckpointHeader { return j.header }
+
+func (j *fakeJournal) WriteHeader(header dto.CheckpointHeader) error {
+	j.header = &header
+	return nil
+}
+
+func (j *fakeJournal) Entries() []dto.CheckpointEntry { return j.entries }
+
+func (j *fakeJournal) Record(entry dto.CheckpointEntry) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.written = append(j.written, entry)
+	return nil
+}
+
+// testFixture monta o use case sobre os repositórios em memória
+type testFixture struct {
+	dealers       *fakeDealerRepo
+	products      *fakeProductRepo
+	productDealer *fakeProductDealerRepo
+	queue         *fakeQueue
+	uc            *ProcessProductsUseCase
+}
+
+// newTestFixture cria o use case com os revendedores e produtos informados (EAN → IdProduto)
+func newTestFixture(dealers map[string]int, products map[string]int) *testFixture {
+	f := &testFixture{
+		dealers:       &fakeDealerRepo{dealers: make(map[string]*entities.Dealer)},
+		products:      &fakeProductRepo{products: make(map[string][]entities.Product)},
+		productDealer: &fakeProductDealerRepo{},
+		queue:         &fakeQueue{},
+	}
+	for ibm, id := range dealers {
+		f.dealers.dealers[ibm] = &entities.Dealer{ID: id, IBM: ibm}
+	}
+	for ean, id := range products {
+		f.products.products[ean] = []entities.Product{{ID: id, EAN: ean}}
+	}
+
+	f.uc = NewProcessProductsUseCase(f.dealers, f.products, f.productDealer, &fakeStagingRepo{products: f.products}, f.queue)
+	f.uc.SetMaxWorkers(4)
+	f.uc.SetRetryPolicy(RetryPolicy{MaxAttempts: 3, BaseDelay: 0, MaxDelay: 0})
+	return f
+}
+
+// pairsInput monta a entrada com o relacionamento IBM → EANs
+func pairsInput(ibmToProducts map[string][]string) dto.ProcessProductsInput {
+	input := dto.ProcessProductsInput{IBMToProducts: ibmToProducts}
+	seen := make(map[string]bool)
+	for ibm, eans := range ibmToProducts {
+		input.IBMCodes = append(input.IBMCodes, ibm)
+		for _, ean := range eans {
+			if !seen[ean] {
+				seen[ean] = true
+				input.ProductCodes = append(input.ProductCodes, ean)
+			}
+		}
+	}
+	sort.Strings(input.IBMCodes)
+	sort.Strings(input.ProductCodes)
+	return input
+}
@@ -0,0 +1,347 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.thiagohmm.com.br/cargaparcial/usecase/dto"
+)
+
+// Estados possíveis de um job assíncrono
+const (
+	JobStateQueued  = "queued"
+	JobStateRunning = "running"
+	JobStateDone    = "done"
+	JobStateFailed  = "failed"
+)
+
+var (
+	// ErrJobNotFound indica que o job não existe ou já expirou
+	ErrJobNotFound = errors.New("job não encontrado")
+	// ErrJobStoreFull indica que o limite de jobs armazenados foi atingido
+	ErrJobStoreFull = errors.New("limite de jobs atingido, tente novamente mais tarde")
+	// ErrJobManagerClosed indica que o gerenciador não aceita mais jobs
+	ErrJobManagerClosed = errors.New("gerenciador de jobs encerrado")
+)
+
+// job mantém o estado de uma execução assíncrona
+type job struct {
+	mu         sync.RWMutex
+	id         string
+	state      string
+	input      dto.ProcessProductsInput
+	output     *dto.ProcessProductsOutput
+	err        error
+	processed  int
+	total      int
+	createdAt  time.Time
+	startedAt  time.Time
+	finishedAt time.Time
+}
+
+// JobManager executa ProcessProductsUseCase de forma assíncrona e guarda os resultados
+type JobManager struct {
+	useCase   *ProcessProductsUseCase
+	maxJobs   int
+	retention time.Duration
+
+	// ctx é cancelado quando o desligamento excede o prazo, interrompendo os jobs em andamento
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu      sync.Mutex
+	jobs    map[string]*job
+	queue   chan *job
+	closed  bool
+	runners sync.WaitGroup
+}
+
+// NewJobManager cria um gerenciador que executa até concurrency jobs ao mesmo
+// tempo, guarda no máximo maxJobs jobs e remove os finalizados após a retenção
+func NewJobManager(useCase *ProcessProductsUseCase, maxJobs, concurrency int, retention time.Duration) *JobManager {
+	if maxJobs <= 0 {
+		maxJobs = 100
+	}
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	m := &JobManager{
+		ctx:       ctx,
+		cancel:    cancel,
+		useCase:   useCase,
+		maxJobs:   maxJobs,
+		retention: retention,
+		jobs:      make(map[string]*job),
+		queue:     make(chan *job, maxJobs),
+	}
+
+	// Cada execução tem seu próprio estado, então vários runners podem consumir a fila
+	for i := 0; i < concurrency; i++ {
+		m.runners.Add(1)
+		go m.runner()
+	}
+
+	return m
+}
+
+// Submit enfileira uma nova execução e retorna o ID do job
+func (m *JobManager) Submit(input dto.ProcessProductsInput) (string, error) {
+	id, err := newJobID()
+	if err != nil {
+		return "", err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.closed {
+		return "", ErrJobManagerClosed
+	}
+
+	m.purgeExpiredLocked()
+	if len(m.jobs) >= m.maxJobs && !m.evictOldestFinishedLocked() {
+		return "", ErrJobStoreFull
+	}
+
+	j := &job{
+		id:        id,
+		state:     JobStateQueued,
+		input:     input,
+		createdAt: time.Now(),
+	}
+	m.jobs[id] = j
+	m.queue <- j
+
+	log.Printf("📥 Job %s enfileirado (%d IBMs, %d códigos)", id, len(input.IBMCodes), len(input.ProductCodes))
+	return id, nil
+}
+
+// Status retorna o estado atual do job
+func (m *JobManager) Status(id string) (*dto.JobStatusDTO, error) {
+	j, err := m.get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	status := &dto.JobStatusDTO{
+		ID:        j.id,
+		Status:    j.state,
+		Processed: j.processed,
+		Total:     j.total,
+		CreatedAt: j.createdAt,
+	}
+
+	if !j.startedAt.IsZero() {
+		startedAt := j.startedAt
+		status.StartedAt = &startedAt
+	}
+	if !j.finishedAt.IsZero() {
+		finishedAt := j.finishedAt
+		status.FinishedAt = &finishedAt
+	}
+	if j.err != nil {
+		status.Error = j.err.Error()
+	}
+
+	// ETA estimado pela taxa média desde o início da execução
+	if j.state == JobStateRunning && j.processed > 0 && j.total > j.processed {
+		elapsed := time.Since(j.startedAt).Seconds()
+		eta := elapsed / float64(j.processed) * float64(j.total-j.processed)
+		status.ETASeconds = &eta
+	}
+
+	return status, nil
+}
+
+// Result retorna o resultado de um job finalizado.
+// Retorna o estado atual junto com nil quando o job ainda não terminou com sucesso.
+func (m *JobManager) Result(id string) (*dto.ProcessProductsOutput, string, error) {
+	j, err := m.get(id)
+	if err != nil {
+		return nil, "", err
+	}
+
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	return j.output, j.state, j.err
+}
+
+// shutdownCancelTimeout é quanto Shutdown aguarda as execuções canceladas gravarem
+// os resultados parciais depois que o prazo do desligamento expira
+const shutdownCancelTimeout = 30 * time.Second
+
+// Shutdown para de aceitar jobs e aguarda as execuções em andamento terminarem.
+// Jobs ainda na fila são marcados como falhos. Se o prazo do contexto expirar,
+// as execuções em andamento são canceladas e retornam resultados parciais; as que não
+// terminarem em shutdownCancelTimeout são abandonadas.
+func (m *JobManager) Shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	if !m.closed {
+		m.closed = true
+		close(m.queue)
+	}
+	m.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		m.runners.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+	}
+
+	// Prazo esgotado: cancelar os jobs e aguardar a gravação dos resultados parciais.
+	// Um worker preso em uma chamada ao banco sem contexto não pode travar o desligamento.
+	m.cancel()
+	timer := time.NewTimer(shutdownCancelTimeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+		return fmt.Errorf("jobs em andamento cancelados no desligamento: %w", ctx.Err())
+	case <-timer.C:
+		return fmt.Errorf("jobs em andamento não terminaram %s após o cancelamento: %w", shutdownCancelTimeout, ctx.Err())
+	}
+}
+
+// runner consome a fila de jobs
+func (m *JobManager) runner() {
+	defer m.runners.Done()
+
+	for j := range m.queue {
+		m.mu.Lock()
+		closed := m.closed
+		m.mu.Unlock()
+
+		if closed {
+			j.finish(nil, errors.New("servidor desligado antes do início da execução"))
+			continue
+		}
+
+		m.run(j)
+	}
+}
+
+// run executa um job e registra o resultado
+func (m *JobManager) run(j *job) {
+	j.mu.Lock()
+	j.state = JobStateRunning
+	j.startedAt = time.Now()
+	input := j.input
+	j.mu.Unlock()
+
+	log.Printf("▶️  Job %s iniciado", j.id)
+
+	output, err := m.useCase.ExecuteWithOptions(m.ctx, input, ExecuteOptions{
+		Progress: func(processed, total int) {
+			j.mu.Lock()
+			j.processed = processed
+			j.total = total
+			j.mu.Unlock()
+		},
+	})
+
+	j.finish(output, err)
+	log.Printf("⏹️  Job %s finalizado", j.id)
+}
+
+// finish registra o resultado final do job
+func (j *job) finish(output *dto.ProcessProductsOutput, err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.finishedAt = time.Now()
+	j.output = output
+	j.err = err
+	// A entrada não é mais necessária depois da execução
+	j.input = dto.ProcessProductsInput{}
+
+	if err != nil {
+		j.state = JobStateFailed
+		return
+	}
+	j.state = JobStateDone
+}
+
+// get busca um job pelo ID, ignorando os expirados
+func (m *JobManager) get(id string) (*job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.purgeExpiredLocked()
+
+	j, ok := m.jobs[id]
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+	return j, nil
+}
+
+// purgeExpiredLocked remove jobs finalizados há mais tempo que a retenção (deve ser chamado com lock)
+func (m *JobManager) purgeExpiredLocked() {
+	if m.retention <= 0 {
+		return
+	}
+
+	for id, j := range m.jobs {
+		j.mu.RLock()
+		expired := !j.finishedAt.IsZero() && time.Since(j.finishedAt) > m.retention
+		j.mu.RUnlock()
+
+		if expired {
+			delete(m.jobs, id)
+		}
+	}
+}
+
+// evictOldestFinishedLocked remove o job finalizado mais antigo para abrir espaço (deve ser chamado com lock)
+func (m *JobManager) evictOldestFinishedLocked() bool {
+	var oldestID string
+	var oldest time.Time
+
+	for id, j := range m.jobs {
+		j.mu.RLock()
+		finishedAt := j.finishedAt
+		j.mu.RUnlock()
+
+		if finishedAt.IsZero() {
+			continue
+		}
+		if oldestID == "" || finishedAt.Before(oldest) {
+			oldestID = id
+			oldest = finishedAt
+		}
+	}
+
+	if oldestID == "" {
+		return false
+	}
+
+	delete(m.jobs, oldestID)
+	return true
+}
+
+// newJobID gera um identificador aleatório para o job
+func newJobID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("erro ao gerar ID do job: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
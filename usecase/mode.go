@@ -0,0 +1,73 @@
+package usecase
+
+import (
+	"fmt"
+
+	"github.thiagohmm.com.br/cargaparcial/domain/entities"
+	"github.thiagohmm.com.br/cargaparcial/usecase/dto"
+)
+
+// OperationMode define o que a execução faz com os pares IBM/EAN da entrada
+type OperationMode string
+
+const (
+	// ModeLink cria (ou reativa) a relação ProdutoRevendedor de cada par (padrão)
+	ModeLink OperationMode = dto.ModeLink
+	// ModeUnlink remove a relação ProdutoRevendedor de cada par, conforme a UnlinkStrategy
+	ModeUnlink OperationMode = dto.ModeUnlink
+	// ModeSync trata a entrada como o sortimento completo de cada loja: vincula os
+	// pares que faltam e remove as relações ativas ausentes da entrada
+	ModeSync OperationMode = dto.ModeSync
+)
+
+// ParseOperationMode converte o valor informado na linha de comando em um OperationMode
+func ParseOperationMode(value string) (OperationMode, error) {
+	switch mode := OperationMode(value); mode {
+	case ModeLink, ModeUnlink, ModeSync:
+		return mode, nil
+	case "":
+		return ModeLink, nil
+	default:
+		return "", fmt.Errorf("modo de operação inválido: %q (use link, unlink ou sync)", value)
+	}
+}
+
+// UnlinkStrategy define como uma relação é removida no modo unlink
+type UnlinkStrategy string
+
+const (
+	// UnlinkDeactivate marca a relação como inativa (StatusProdutoRevendedor = 0)
+	UnlinkDeactivate UnlinkStrategy = "deactivate"
+	// UnlinkDelete exclui o registro de ProdutoRevendedor
+	UnlinkDelete UnlinkStrategy = "delete"
+)
+
+// ParseUnlinkStrategy converte o valor informado na configuração em uma UnlinkStrategy
+func ParseUnlinkStrategy(value string) (UnlinkStrategy, error) {
+	switch strategy := UnlinkStrategy(value); strategy {
+	case UnlinkDeactivate, UnlinkDelete:
+		return strategy, nil
+	default:
+		return "", fmt.Errorf("estratégia de desvinculação inválida: %q (use deactivate ou delete)", value)
+	}
+}
+
+// planUnlink converte o estado atual das relações (retornado por PlanBatch) no
+// resultado que a remoção teria com a estratégia informada
+func (s UnlinkStrategy) planUnlink(statuses []entities.RelationStatus) []entities.RelationStatus {
+	planned := make([]entities.RelationStatus, len(statuses))
+	for i, status := range statuses {
+		switch {
+		case status == entities.RelationCreated:
+			// PlanBatch criaria a relação: ela não existe
+			planned[i] = entities.RelationNotFound
+		case s == UnlinkDelete:
+			planned[i] = entities.RelationDeleted
+		case status == entities.RelationReactivated:
+			planned[i] = entities.RelationAlreadyInactive
+		default:
+			planned[i] = entities.RelationDeactivated
+		}
+	}
+	return planned
+}
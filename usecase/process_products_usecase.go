@@ -1,25 +1,20 @@
 package usecase
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"runtime"
+	"sort"
 	"sync"
-	"sync/atomic"
-	"time"
 
 	"github.thiagohmm.com.br/cargaparcial/domain/entities"
 	"github.thiagohmm.com.br/cargaparcial/domain/repositories"
 	"github.thiagohmm.com.br/cargaparcial/domain/services"
+	"github.thiagohmm.com.br/cargaparcial/domain/validation"
 	"github.thiagohmm.com.br/cargaparcial/usecase/dto"
 )
 
-var (
-	processedItems  int64
-	startTime       time.Time
-	lastProgressLog time.Time
-)
-
 // ProcessProductsUseCase implementa a lógica de negócio para processar produtos
 type ProcessProductsUseCase struct {
 	dealerRepo             repositories.DealerRepository
@@ -30,11 +25,13 @@ type ProcessProductsUseCase struct {
 	maxWorkers             int
 	dealerCache            map[string]*entities.Dealer // Cache de dealers
 	dealerCacheMutex       sync.RWMutex                // Mutex para acesso seguro ao cache
-	
-	// Batch processing
-	batchProductDealers      []*entities.ProductDealer
-	batchProductDealersMutex sync.Mutex
-	batchSize                int
+	batchSize              int                         // Tamanho do batch de ProductDealers por execução
+	ambiguityPolicy        AmbiguityPolicy             // Tratamento de EANs com mais de um produto
+	gtinOptions            validation.GTINOptions      // Validação e normalização dos EANs da entrada
+	ibmOptions             validation.IBMOptions       // Normalização dos IBMs da entrada
+	unlinkStrategy         UnlinkStrategy              // Remoção das relações no modo unlink
+	syncMaxRemoval         float64                     // Percentual máximo de relações ativas removidas por loja no modo sync
+	retryPolicy            RetryPolicy                 // Novas tentativas para erros transitórios do banco
 }
 
 // NewProcessProductsUseCase cria uma nova instância do use case
@@ -60,8 +57,12 @@ func NewProcessProductsUseCase(
 		queueService:           queueService,
 		maxWorkers:             maxWorkers,
 		dealerCache:            make(map[string]*entities.Dealer),
-		batchProductDealers:    make([]*entities.ProductDealer, 0, 500),
 		batchSize:              100, // Flush a cada 100 items
+		ambiguityPolicy:        AmbiguityFail,
+		ibmOptions:             validation.DefaultIBMOptions(),
+		unlinkStrategy:         UnlinkDeactivate,
+		syncMaxRemoval:         DefaultSyncMaxRemoval,
+		retryPolicy:            DefaultRetryPolicy,
 	}
 }
 
@@ -72,21 +73,165 @@ func (uc *ProcessProductsUseCase) SetMaxWorkers(workers int) {
 	}
 }
 
+// SetAmbiguityPolicy define o tratamento de EANs associados a mais de um produto
+func (uc *ProcessProductsUseCase) SetAmbiguityPolicy(policy AmbiguityPolicy) {
+	uc.ambiguityPolicy = policy
+}
+
+// SetGTINOptions define como os EANs da entrada são validados e normalizados
+// antes da consulta ao banco
+func (uc *ProcessProductsUseCase) SetGTINOptions(opts validation.GTINOptions) {
+	uc.gtinOptions = opts
+}
+
+// SetIBMOptions define como os IBMs da entrada são normalizados antes da busca do revendedor
+func (uc *ProcessProductsUseCase) SetIBMOptions(opts validation.IBMOptions) {
+	uc.ibmOptions = opts
+}
+
+// SetUnlinkStrategy define se o modo unlink desativa ou exclui as relações
+func (uc *ProcessProductsUseCase) SetUnlinkStrategy(strategy UnlinkStrategy) {
+	uc.unlinkStrategy = strategy
+}
+
 // JobInput representa um trabalho a ser processado
 type JobInput struct {
 	Dealer      *entities.Dealer
 	ProductCode string
+	Removal     bool // Relação fora da entrada removida pela sincronização (ProductCode é só o EAN de exibição)
+}
+
+// jobResult associa o resultado ao job que o originou
+type jobResult struct {
+	job      JobInput
+	result   dto.ProductResultDTO
+	restored bool // resultado recuperado do journal de checkpoint
+}
+
+// ProgressFunc recebe o número de itens processados e o total de itens da execução.
+// Em ExecuteStream o total cresce a cada bloco lido da entrada.
+type ProgressFunc func(processed, total int)
+
+// ExecuteOptions contém opções de uma execução que não fazem parte da entrada
+type ExecuteOptions struct {
+	// Progress é chamado após cada item processado (opcional)
+	Progress ProgressFunc
+	// Journal registra cada par concluído e permite pular os já concluídos (opcional)
+	Journal CheckpointJournal
+	// DryRun resolve revendedores, EANs e o estado das relações sem gravar nada:
+	// nem ProdutoRevendedor, nem staging, nem a mensagem "mover". O journal é ignorado.
+	DryRun bool
+	// Mode define se os pares são vinculados (padrão) ou desvinculados dos revendedores.
+	// No modo unlink a procedure de staging é chamada para os pares removidos, para que
+	// a integração propague a remoção.
+	Mode OperationMode
+	// Sink recebe cada resultado assim que o par é concluído (opcional), para que os
+	// resultados fiquem gravados mesmo se o processo morrer antes do fim
+	Sink ResultSink
+	// KeepResults acumula os resultados nas listas do output mesmo com Sink. Por padrão,
+	// com Sink os resultados vão apenas para ele (e para Totals), mantendo a memória
+	// constante; sem Sink as listas são sempre preenchidas.
+	KeepResults bool
+}
+
+// DiscardsResults indica se os resultados deixam de ser acumulados nas listas do output
+func (o ExecuteOptions) DiscardsResults() bool {
+	return o.Sink != nil && !o.KeepResults
 }
 
 // Execute executa o processamento de produtos com paralelização
-func (uc *ProcessProductsUseCase) Execute(input dto.ProcessProductsInput) (*dto.ProcessProductsOutput, error) {
-	// Reset contadores globais
-	atomic.StoreInt64(&processedItems, 0)
-	startTime = time.Now()
-	lastProgressLog = time.Now()
+func (uc *ProcessProductsUseCase) Execute(ctx context.Context, input dto.ProcessProductsInput) (*dto.ProcessProductsOutput, error) {
+	return uc.ExecuteWithOptions(ctx, input, ExecuteOptions{})
+}
+
+// ExecuteWithOptions executa o processamento de produtos com as opções informadas.
+// Quando o contexto é cancelado, para de despachar jobs, grava o batch pendente e
+// retorna o resultado parcial com os pares restantes em NotProcessedList.
+func (uc *ProcessProductsUseCase) ExecuteWithOptions(ctx context.Context, input dto.ProcessProductsInput, opts ExecuteOptions) (*dto.ProcessProductsOutput, error) {
+	// Estado isolado desta execução (contadores e timers)
+	run, err := uc.newProcessRun(opts)
+	if err != nil {
+		return nil, err
+	}
 
 	log.Printf("Iniciando processamento paralelo com %d workers", uc.maxWorkers)
 
+	// Sem acumular resultados, as listas não precisam de capacidade reservada
+	totalItems := len(input.IBMCodes) * len(input.ProductCodes)
+	if opts.DiscardsResults() {
+		totalItems = 0
+	}
+	output := &dto.ProcessProductsOutput{
+		SchemaVersion: dto.OutputSchemaVersion,
+		SuccessList:   make([]dto.ProductResultDTO, 0, totalItems/2),
+		FailureList:   make([]dto.ProductResultDTO, 0, totalItems/10),
+		DryRun:        opts.DryRun,
+		Mode:          string(run.mode()),
+	}
+
+	if err := run.process(ctx, input, output); err != nil {
+		return nil, err
+	}
+
+	run.finish(ctx, output)
+	return output, nil
+}
+
+// process executa o pipeline completo para os pares da entrada, acumulando os
+// resultados em output. Pode ser chamado várias vezes na mesma execução (um bloco
+// de pares por chamada) quando a entrada é lida em streaming.
+func (r *processRun) process(ctx context.Context, input dto.ProcessProductsInput, output *dto.ProcessProductsOutput) error {
+	uc := r.uc
+	opts := r.opts
+	completed := r.completed
+
+	// IBMs que perderam zeros à esquerda (ou com formatação) passam a casar com Revendedor.CodigoIBM
+	input = normalizeIBMs(input, uc.ibmOptions)
+
+	// Pré-carregar dealers no cache para evitar consultas repetidas
+	dealerMap, missingIBMs, cancelledIBMs, err := uc.preloadDealers(ctx, input)
+	if err != nil {
+		return err
+	}
+	ibmSuggestions := r.suggestIBMs(ctx, missingIBMs)
+
+	// Resolver os EANs distintos de uma vez, em vez de uma consulta por job.
+	// EANs inválidos não chegam ao banco: seus pares falham na resolução.
+	// Na sincronização os pares já concluídos também são resolvidos: sem seus produtos,
+	// planSync os trataria como ausentes da entrada e removeria as relações.
+	skipped := completed
+	if r.mode() == ModeSync {
+		skipped = nil
+	}
+	eans, invalidEANs := distinctEANs(input, dealerMap, skipped, uc.gtinOptions)
+	if len(invalidEANs) > 0 {
+		log.Printf("⚠️  %d EANs inválidos não serão consultados no banco", len(invalidEANs))
+		output.InvalidEANs = mergeSorted(output.InvalidEANs, invalidEANs)
+	}
+	productsByEAN, err := uc.productRepo.GetByEANs(ctx, eans)
+	if err != nil && ctx.Err() == nil {
+		return fmt.Errorf("erro ao resolver EANs: %w", err)
+	}
+	// Se a resolução foi interrompida pelo cancelamento, o mapa fica vazio e
+	// os pares restantes são listados como não processados
+	r.productsByEAN = productsByEAN
+
+	if err == nil {
+		unresolvedEANs := missingEANs(eans, productsByEAN)
+		log.Printf("🔎 %d EANs distintos resolvidos, %d não encontrados", len(eans)-len(unresolvedEANs), len(unresolvedEANs))
+		output.UnresolvedEANs = mergeSorted(output.UnresolvedEANs, unresolvedEANs)
+	}
+
+	// Sincronização: relações ativas ausentes da entrada são removidas. O plano é
+	// calculado antes de qualquer gravação e aborta se exceder o limite de remoção.
+	var removals []resolvedPair
+	if r.mode() == ModeSync && ctx.Err() == nil {
+		removals, err = r.planSync(ctx, input, dealerMap)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Calcular tamanho do buffer baseado no volume de trabalho
 	totalItems := len(input.IBMCodes) * len(input.ProductCodes)
 	bufferSize := 1000
@@ -94,76 +239,149 @@ func (uc *ProcessProductsUseCase) Execute(input dto.ProcessProductsInput) (*dto.
 		bufferSize = totalItems
 	}
 
-	// Canais para comunicação entre goroutines com buffer maior
+	// Canais entre os estágios do pipeline:
+	// jobs → resolução (mapa de EANs) → relations → batch de relações → staging → procedure → results
 	jobs := make(chan JobInput, bufferSize)
-	results := make(chan dto.ProductResultDTO, bufferSize)
+	relations := make(chan resolvedPair, bufferSize)
+	staging := make(chan resolvedPair, bufferSize)
+	results := make(chan jobResult, bufferSize)
 
-	// WaitGroup para aguardar conclusão de todos os workers
-	var wg sync.WaitGroup
+	// WaitGroups para aguardar conclusão dos workers de cada estágio
+	var resolveWg, stagingWg sync.WaitGroup
 
-	// Iniciar workers
+	// Iniciar workers de resolução
 	for w := 1; w <= uc.maxWorkers; w++ {
-		wg.Add(1)
-		go uc.worker(w, jobs, results, &wg)
+		resolveWg.Add(1)
+		go r.resolveWorker(ctx, w, jobs, relations, results, &resolveWg)
 	}
 
-	// Goroutine para coletar resultados
-	output := &dto.ProcessProductsOutput{
-		SuccessList: make([]dto.ProductResultDTO, 0, totalItems/2),
-		FailureList: make([]dto.ProductResultDTO, 0, totalItems/10),
+	// Remoções da sincronização já têm produto e revendedor: vão direto ao estágio de relações
+	if len(removals) > 0 {
+		resolveWg.Add(1)
+		go func() {
+			defer resolveWg.Done()
+			for _, pair := range removals {
+				if ctx.Err() != nil {
+					results <- jobResult{job: pair.job, result: notProcessedResult(pair.job)}
+					continue
+				}
+				relations <- pair
+			}
+		}()
 	}
 
+	go func() {
+		resolveWg.Wait()
+		close(relations)
+	}()
+
+	// Estágio único de relações: garante a gravação antes do staging
+	go func() {
+		r.relationStage(ctx, relations, staging, results)
+		close(staging)
+	}()
+
+	// Iniciar workers de staging
+	for w := 1; w <= uc.maxWorkers; w++ {
+		stagingWg.Add(1)
+		go r.stagingWorker(ctx, w, staging, results, &stagingWg)
+	}
+
+	// Total de jobs planejados, incluindo os pares de IBMs não encontrados ou não consultados
+	r.plannedJobs += countPlannedJobs(input, dealerMap, missingIBMs, cancelledIBMs) + len(removals)
+
+	// Goroutine para coletar resultados
+	sink := r.sink(output)
 	var resultWg sync.WaitGroup
 	resultWg.Add(1)
 	go func() {
 		defer resultWg.Done()
-		for result := range results {
-			if result.Status == "ok" {
-				output.SuccessList = append(output.SuccessList, result)
-			} else {
-				output.FailureList = append(output.FailureList, result)
+		for jr := range results {
+			result := jr.result
+
+			// IBM e EAN identificam o par no resultado, inclusive nos pares resolvidos
+			if result.IBM == "" {
+				result.IBM = jr.job.Dealer.IBM
+			}
+			if result.EAN == "" {
+				result.EAN = jr.job.ProductCode
+			}
+			if result.SourceRows == nil {
+				result.SourceRows = input.SourceRows[[2]string{jr.job.Dealer.IBM, jr.job.ProductCode}]
+			}
+			result.SyncRemoval = jr.job.Removal
+
+			// Registrar no journal apenas resultados definitivos desta execução
+			if opts.Journal != nil && !jr.restored && journaled(result) {
+				entry := dto.CheckpointEntry{IBM: jr.job.Dealer.IBM, EAN: jr.job.ProductCode, Result: result}
+				if err := opts.Journal.Record(entry); err != nil {
+					r.journalErrors++
+					if r.journalErrors == 1 {
+						log.Printf("⚠️  Erro ao gravar journal de checkpoint: %v", err)
+					}
+				}
+			}
+
+			countResult(&output.Totals, result)
+			if err := sink.Write(result); err != nil {
+				r.sinkErrors++
+				if r.sinkErrors == 1 {
+					log.Printf("⚠️  Erro ao gravar resultado: %v", err)
+				}
+			}
+
+			r.collected++
+			r.recordProcessed()
+			if opts.Progress != nil {
+				opts.Progress(r.collected, r.plannedJobs)
 			}
 		}
 	}()
 
-	// Pré-carregar dealers no cache para evitar consultas repetidas
-	dealerMap := make(map[string]*entities.Dealer)
-	for _, ibmCode := range input.IBMCodes {
-		if ibmCode == "" {
-			ibmCode = "0"
-		}
+	// Enviar jobs para processamento
+	totalJobs := 0
 
-		// Verificar cache primeiro
-		uc.dealerCacheMutex.RLock()
-		dealer, cached := uc.dealerCache[ibmCode]
-		uc.dealerCacheMutex.RUnlock()
+	// dispatch envia o job aos workers ou, após o cancelamento, registra o par como não processado.
+	// Pares já concluídos no journal são reaproveitados sem nova chamada ao banco.
+	dispatch := func(job JobInput) {
+		if previous, ok := completed[pairKey{ibm: job.Dealer.IBM, ean: job.ProductCode}]; ok {
+			results <- jobResult{job: job, result: previous, restored: true}
+			return
+		}
 
-		if !cached {
-			// Buscar revendedor por IBM
-			var err error
-			dealer, err = uc.dealerRepo.GetByIBM(ibmCode)
-			if err != nil {
-				log.Printf("Erro ao buscar revendedor por IBM %s: %v", ibmCode, err)
-				continue
+		if ctx.Err() == nil {
+			select {
+			case jobs <- job:
+				totalJobs++
+				return
+			case <-ctx.Done():
 			}
+		}
+		results <- jobResult{job: job, result: notProcessedResult(job)}
+	}
+
+	// IBMs não consultados por cancelamento também são listados como não processados
+	for _, ibmCode := range cancelledIBMs {
+		dealer := &entities.Dealer{IBM: ibmCode}
+		for _, productCode := range productsForIBM(input, ibmCode) {
+			dispatch(JobInput{Dealer: dealer, ProductCode: productCode})
+		}
+	}
 
-			if dealer == nil {
-				log.Printf("Revendedor não encontrado para IBM: %s", ibmCode)
+	// Produtos de IBMs sem revendedor cadastrado são reportados como falha,
+	// para que os totais do resultado batam com as linhas da entrada
+	for _, ibmCode := range missingIBMs {
+		dealer := &entities.Dealer{IBM: ibmCode}
+		for _, productCode := range productsForIBM(input, ibmCode) {
+			job := JobInput{Dealer: dealer, ProductCode: productCode}
+			if previous, ok := completed[pairKey{ibm: ibmCode, ean: productCode}]; ok {
+				results <- jobResult{job: job, result: previous, restored: true}
 				continue
 			}
-
-			// Adicionar ao cache
-			uc.dealerCacheMutex.Lock()
-			uc.dealerCache[ibmCode] = dealer
-			uc.dealerCacheMutex.Unlock()
+			results <- jobResult{job: job, result: dealerNotFoundResult(job, ibmSuggestions[ibmCode])}
 		}
-
-		dealerMap[ibmCode] = dealer
 	}
 
-	// Enviar jobs para processamento
-	totalJobs := 0
-
 	// Se temos o mapeamento IBM -> Produtos, usar ele
 	if len(input.IBMToProducts) > 0 {
 		log.Println("📋 Usando relacionamento IBM → Produtos do arquivo")
@@ -178,11 +396,10 @@ func (uc *ProcessProductsUseCase) Execute(input dto.ProcessProductsInput) (*dto.
 
 			// Enviar jobs apenas para os produtos deste IBM
 			for _, productCode := range products {
-				jobs <- JobInput{
+				dispatch(JobInput{
 					Dealer:      dealer,
 					ProductCode: productCode,
-				}
-				totalJobs++
+				})
 			}
 		}
 	} else {
@@ -192,11 +409,10 @@ func (uc *ProcessProductsUseCase) Execute(input dto.ProcessProductsInput) (*dto.
 		for ibmCode, dealer := range dealerMap {
 			// Enviar jobs para cada produto
 			for _, productCode := range input.ProductCodes {
-				jobs <- JobInput{
+				dispatch(JobInput{
 					Dealer:      dealer,
 					ProductCode: productCode,
-				}
-				totalJobs++
+				})
 			}
 			_ = ibmCode // evita warning unused
 		}
@@ -207,8 +423,9 @@ func (uc *ProcessProductsUseCase) Execute(input dto.ProcessProductsInput) (*dto.
 	// Fechar canal de jobs (não haverá mais trabalhos)
 	close(jobs)
 
-	// Aguardar todos os workers terminarem
-	wg.Wait()
+	// Aguardar todos os estágios terminarem (o staging só fecha após a resolução
+	// e o flush final do batch de relações)
+	stagingWg.Wait()
 
 	// Fechar canal de resultados
 	close(results)
@@ -216,177 +433,255 @@ func (uc *ProcessProductsUseCase) Execute(input dto.ProcessProductsInput) (*dto.
 	// Aguardar coleta de todos os resultados
 	resultWg.Wait()
 
-	log.Printf("Processamento concluído: %d jobs processados", totalJobs)
-	log.Printf("Sucessos: %d, Falhas: %d", len(output.SuccessList), len(output.FailureList))
+	r.dispatchedJobs += totalJobs
+	return nil
+}
+
+// finish marca o cancelamento, registra os totais e avisa a integração
+func (r *processRun) finish(ctx context.Context, output *dto.ProcessProductsOutput) {
+	output.Cancelled = ctx.Err() != nil
+
+	log.Printf("Processamento concluído (modo %s): %d jobs processados", r.mode(), r.dispatchedJobs)
+	log.Printf("Sucessos: %d, Falhas: %d", output.Totals.Ok, output.Totals.Fail)
+	if output.Cancelled {
+		log.Printf("⚠️  Processamento cancelado: %d pares não processados", output.Totals.NotProcessed)
+	}
+	if r.sinkErrors > 0 {
+		log.Printf("⚠️  %d resultados não puderam ser gravados no destino configurado", r.sinkErrors)
+	}
+	if retries := r.retries.Load(); retries > 0 {
+		log.Printf("🔁 %d novas tentativas por erro transitório do banco", retries)
+	}
 
-	// Flush final do batch de ProductDealers
-	if err := uc.flushProductDealerBatch(); err != nil {
-		log.Printf("Erro ao fazer flush final do batch: %v", err)
+	if r.opts.DryRun {
+		log.Println("🧪 Simulação: nenhuma alteração gravada e mensagem \"mover\" não enviada")
+		return
 	}
 
 	// Enviar mensagem "mover" para a fila "integracao"
-	if err := uc.queueService.Send("mover"); err != nil {
+	if err := r.uc.queueService.Send("mover"); err != nil {
 		log.Printf("Erro ao enviar mensagem para fila: %v", err)
 	}
-
-	return output, nil
 }
 
-// worker processa jobs do canal
-func (uc *ProcessProductsUseCase) worker(id int, jobs <-chan JobInput, results chan<- dto.ProductResultDTO, wg *sync.WaitGroup) {
-	defer wg.Done()
-
-	processedCount := 0
-	for job := range jobs {
-		result := uc.processProduct(job.Dealer, job.ProductCode)
-		results <- result
-		processedCount++
-
-		// Incrementa contador global
-		total := atomic.AddInt64(&processedItems, 1)
-
-		// Log de progresso a cada 5 segundos
-		if time.Since(lastProgressLog) >= 5*time.Second {
-			lastProgressLog = time.Now()
-			elapsed := time.Since(startTime).Seconds()
-			rate := float64(total) / elapsed
-			log.Printf("⚡ Progresso: %d itens | %.0f items/seg | Tempo: %.1fs", total, rate, elapsed)
-		}
+// suggestIBMs sugere, para cada IBM sem revendedor, os IBMs cadastrados mais parecidos
+// (possível erro de digitação). Os IBMs cadastrados são consultados uma vez por execução;
+// se a consulta falhar, as falhas seguem sem sugestões.
+func (r *processRun) suggestIBMs(ctx context.Context, missingIBMs []string) map[string][]string {
+	if len(missingIBMs) == 0 || ctx.Err() != nil {
+		return nil
 	}
 
-	log.Printf("Worker %d finalizado: processou %d itens no total", id, processedCount)
-}
-
-// processProduct processa um único produto para um revendedor
-func (uc *ProcessProductsUseCase) processProduct(dealer *entities.Dealer, productCode string) dto.ProductResultDTO {
-	dealerID := dealer.ID
-
-	// Buscar produto por EAN
-	products, err := uc.productRepo.GetByEAN(productCode)
-	if err != nil || len(products) == 0 {
-		return dto.ProductResultDTO{
-			DealerID:  &dealerID,
-			ProductID: nil,
-			EAN:       productCode,
-			Status:    "fail",
-			Reason:    "Produto não encontrado pelo EAN",
+	if r.registeredIBMs == nil {
+		registered, err := r.uc.dealerRepo.ListIBMs(ctx)
+		if err != nil {
+			log.Printf("⚠️  Erro ao listar IBMs cadastrados, falhas sem sugestões de IBM: %v", err)
+			return nil
 		}
+		r.registeredIBMs = append([]string{}, registered...)
 	}
 
-	product := products[0]
-	productID := product.ID
-
-	// Verificar se já existe relação ProductDealer
-	exists, err := uc.productDealerRepo.Exists(productID, dealerID)
-	if err != nil {
-		log.Printf("Erro ao verificar ProductDealer: %v", err)
-		return dto.ProductResultDTO{
-			DealerID:  &dealerID,
-			ProductID: &productID,
-			Status:    "fail",
-			Reason:    "Erro ao verificar relação produto-revendedor",
+	suggestions := make(map[string][]string, len(missingIBMs))
+	for _, ibmCode := range missingIBMs {
+		if suggested := validation.SuggestIBMs(ibmCode, r.registeredIBMs, validation.DefaultSuggestDistance, validation.DefaultSuggestLimit); len(suggested) > 0 {
+			suggestions[ibmCode] = suggested
 		}
 	}
+	if len(suggestions) > 0 {
+		log.Printf("💡 %d de %d IBMs sem revendedor têm IBMs parecidos cadastrados (veja SugestoesIBM em arrayFail)", len(suggestions), len(missingIBMs))
+	}
+	return suggestions
+}
 
-	// Criar relação se não existir - usando BATCH
-	if !exists {
-		productDealer := &entities.ProductDealer{
-			ProductID: productID,
-			DealerID:  dealerID,
-			IsActive:  true,
+// preloadDealers busca de uma vez os revendedores dos IBMs da entrada que não estão
+// no cache do use case. Retorna os IBMs sem revendedor cadastrado e, se o contexto
+// for cancelado antes da consulta, os IBMs que não chegaram a ser consultados.
+func (uc *ProcessProductsUseCase) preloadDealers(ctx context.Context, input dto.ProcessProductsInput) (dealerMap map[string]*entities.Dealer, missingIBMs, cancelledIBMs []string, err error) {
+	dealerMap = make(map[string]*entities.Dealer)
+
+	// IBMs distintos fora do cache
+	var pending []string
+	seen := make(map[string]bool)
+	for _, ibmCode := range input.IBMCodes {
+		if ibmCode == "" {
+			ibmCode = "0"
+		}
+		if seen[ibmCode] {
+			continue
 		}
+		seen[ibmCode] = true
 
-		// Adiciona ao batch (faz flush automático se necessário)
-		if err := uc.addToProductDealerBatch(productDealer); err != nil {
-			log.Printf("Erro ao adicionar ProductDealer ao batch: %v", err)
-			return dto.ProductResultDTO{
-				DealerID:  &dealerID,
-				ProductID: &productID,
-				Status:    "fail",
-				Reason:    "Erro ao criar relação produto-revendedor (batch)",
-			}
+		// Verificar cache primeiro
+		uc.dealerCacheMutex.RLock()
+		dealer, cached := uc.dealerCache[ibmCode]
+		uc.dealerCacheMutex.RUnlock()
+
+		if cached {
+			dealerMap[ibmCode] = dealer
+			continue
 		}
+		pending = append(pending, ibmCode)
 	}
 
-	// Gravar integração produto staging (chama a stored procedure)
-	if err := uc.productRepo.SaveIntegrationStaging(dealerID, productID); err != nil {
-		log.Printf("Erro ao gravar integração produto staging: %v", err)
-		return dto.ProductResultDTO{
-			DealerID:  &dealerID,
-			ProductID: &productID,
-			Status:    "fail",
-			Reason:    "Erro ao gravar integração produto staging",
-		}
+	if len(pending) == 0 {
+		return dealerMap, nil, nil, nil
+	}
+	if ctx.Err() != nil {
+		return dealerMap, nil, pending, nil
 	}
 
-	// Verificar se o registro foi realmente inserido na tabela IntegracaoProdutoStaging
-	// (igual ao código TypeScript que faz productIntegrationStagingQuery.getByProductIntegrationStaging)
-	staging, err := uc.productIntegrationRepo.GetByProductAndDealer(productID, dealerID)
+	found, err := uc.dealerRepo.GetByIBMs(ctx, pending)
 	if err != nil {
-		log.Printf("Erro ao verificar ProductIntegrationStaging: %v", err)
-		return dto.ProductResultDTO{
-			DealerID:  &dealerID,
-			ProductID: &productID,
-			Status:    "fail",
-			Reason:    "Erro ao verificar integração produto staging",
+		if ctx.Err() != nil {
+			return dealerMap, nil, pending, nil
 		}
+		return nil, nil, nil, fmt.Errorf("erro ao buscar revendedores: %w", err)
 	}
 
-	// Se o registro existe, retorna sucesso. Caso contrário, falha.
-	if staging != nil {
-		return dto.ProductResultDTO{
-			DealerID:  &dealerID,
-			ProductID: &productID,
-			Status:    "ok",
+	// Adicionar ao cache
+	uc.dealerCacheMutex.Lock()
+	for _, ibmCode := range pending {
+		dealer, ok := found[ibmCode]
+		if !ok {
+			missingIBMs = append(missingIBMs, ibmCode)
+			continue
 		}
+		uc.dealerCache[ibmCode] = dealer
+		dealerMap[ibmCode] = dealer
 	}
+	uc.dealerCacheMutex.Unlock()
 
-	return dto.ProductResultDTO{
-		DealerID:  &dealerID,
-		ProductID: &productID,
-		Status:    "fail",
-		Reason:    "Registro não encontrado após chamada da procedure",
+	if len(missingIBMs) > 0 {
+		log.Printf("⚠️  %d IBMs sem revendedor cadastrado: seus produtos serão listados como falha", len(missingIBMs))
 	}
+
+	return dealerMap, missingIBMs, nil, nil
 }
 
-// addToProductDealerBatch adiciona um ProductDealer ao batch e faz flush se necessário
-func (uc *ProcessProductsUseCase) addToProductDealerBatch(productDealer *entities.ProductDealer) error {
-	uc.batchProductDealersMutex.Lock()
-	defer uc.batchProductDealersMutex.Unlock()
+// distinctEANs retorna os EANs normalizados distintos dos pares que serão despachados,
+// ignorando os pares já concluídos no journal, e, ordenados, os códigos da entrada
+// que não passaram na validação
+func distinctEANs(input dto.ProcessProductsInput, dealerMap map[string]*entities.Dealer, completed map[pairKey]dto.ProductResultDTO, gtinOptions validation.GTINOptions) (eans, invalid []string) {
+	seen := make(map[string]bool)
+	seenInvalid := make(map[string]bool)
+	for ibmCode := range dealerMap {
+		for _, productCode := range productsForIBM(input, ibmCode) {
+			if _, done := completed[pairKey{ibm: ibmCode, ean: productCode}]; done {
+				continue
+			}
 
-	uc.batchProductDealers = append(uc.batchProductDealers, productDealer)
+			ean, err := gtinOptions.Normalize(productCode)
+			if err != nil {
+				if !seenInvalid[productCode] {
+					seenInvalid[productCode] = true
+					invalid = append(invalid, productCode)
+				}
+				continue
+			}
 
-	// Se atingiu o tamanho do batch, faz o flush
-	if len(uc.batchProductDealers) >= uc.batchSize {
-		return uc.flushProductDealerBatchUnsafe()
+			if !seen[ean] {
+				seen[ean] = true
+				eans = append(eans, ean)
+			}
+		}
 	}
-
-	return nil
+	sort.Strings(invalid)
+	return eans, invalid
 }
 
-// flushProductDealerBatch faz o flush do batch com lock
-func (uc *ProcessProductsUseCase) flushProductDealerBatch() error {
-	uc.batchProductDealersMutex.Lock()
-	defer uc.batchProductDealersMutex.Unlock()
-
-	return uc.flushProductDealerBatchUnsafe()
+// missingEANs retorna, ordenados, os EANs sem produto correspondente
+func missingEANs(eans []string, productsByEAN map[string][]entities.Product) []string {
+	var missing []string
+	for _, ean := range eans {
+		if len(productsByEAN[ean]) == 0 {
+			missing = append(missing, ean)
+		}
+	}
+	sort.Strings(missing)
+	return missing
 }
 
-// flushProductDealerBatchUnsafe faz o flush sem lock (deve ser chamado com lock já adquirido)
-func (uc *ProcessProductsUseCase) flushProductDealerBatchUnsafe() error {
-	if len(uc.batchProductDealers) == 0 {
-		return nil
+// mergeSorted une duas listas ordenadas de EANs, sem repetições
+func mergeSorted(a, b []string) []string {
+	if len(a) == 0 {
+		return b
 	}
+	merged := make([]string, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		var next string
+		switch {
+		case j >= len(b) || (i < len(a) && a[i] < b[j]):
+			next = a[i]
+			i++
+		case i >= len(a) || b[j] < a[i]:
+			next = b[j]
+			j++
+		default:
+			next = a[i]
+			i++
+			j++
+		}
+		if len(merged) == 0 || merged[len(merged)-1] != next {
+			merged = append(merged, next)
+		}
+	}
+	return merged
+}
 
-	log.Printf("🚀 Fazendo batch insert de %d ProductDealers", len(uc.batchProductDealers))
+// normalizeIBMs normaliza os IBMs da entrada, unindo os produtos de códigos
+// que passam a ser iguais após a normalização
+func normalizeIBMs(input dto.ProcessProductsInput, ibmOptions validation.IBMOptions) dto.ProcessProductsInput {
+	normalized := dto.ProcessProductsInput{
+		IBMCodes:     make([]string, 0, len(input.IBMCodes)),
+		ProductCodes: input.ProductCodes,
+	}
+	if len(input.IBMToProducts) > 0 {
+		normalized.IBMToProducts = make(map[string][]string, len(input.IBMToProducts))
+	}
+	if len(input.SourceRows) > 0 {
+		normalized.SourceRows = make(map[[2]string][]string, len(input.SourceRows))
+	}
 
-	err := uc.productDealerRepo.CreateBatch(uc.batchProductDealers)
-	if err != nil {
-		return fmt.Errorf("erro ao criar batch de ProductDealers: %w", err)
+	seen := make(map[string]bool, len(input.IBMCodes))
+	for _, ibmCode := range input.IBMCodes {
+		code := ibmOptions.Normalize(ibmCode)
+		if !seen[code] {
+			seen[code] = true
+			normalized.IBMCodes = append(normalized.IBMCodes, code)
+		}
+	}
+	for ibmCode, products := range input.IBMToProducts {
+		code := ibmOptions.Normalize(ibmCode)
+		normalized.IBMToProducts[code] = append(normalized.IBMToProducts[code], products...)
+	}
+	for key, rows := range input.SourceRows {
+		key[0] = ibmOptions.Normalize(key[0])
+		normalized.SourceRows[key] = append(normalized.SourceRows[key], rows...)
 	}
 
-	// Limpar o batch
-	uc.batchProductDealers = uc.batchProductDealers[:0]
+	return normalized
+}
 
-	return nil
+// productsForIBM retorna os códigos de produto associados ao IBM na entrada
+func productsForIBM(input dto.ProcessProductsInput, ibmCode string) []string {
+	if len(input.IBMToProducts) > 0 {
+		return input.IBMToProducts[ibmCode]
+	}
+	return input.ProductCodes
+}
+
+// countPlannedJobs calcula quantos jobs serão enviados para os dealers encontrados
+// somados aos pares dos IBMs informados em unresolvedIBMs
+func countPlannedJobs(input dto.ProcessProductsInput, dealerMap map[string]*entities.Dealer, unresolvedIBMs ...[]string) int {
+	total := 0
+	for ibmCode := range dealerMap {
+		total += len(productsForIBM(input, ibmCode))
+	}
+	for _, ibmCodes := range unresolvedIBMs {
+		for _, ibmCode := range ibmCodes {
+			total += len(productsForIBM(input, ibmCode))
+		}
+	}
+	return total
 }
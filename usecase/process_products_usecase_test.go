@@ -0,0 +1,454 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.thiagohmm.com.br/cargaparcial/usecase/dto"
+)
+
+// testEAN gera um EAN-13 válido a partir de n
+func testEAN(n int) string {
+	base := fmt.Sprintf("789%09d", n)
+	sum := 0
+	for i := len(base) - 1; i >= 0; i-- {
+		digit := int(base[i] - '0')
+		if (len(base)-1-i)%2 == 0 {
+			digit *= 3
+		}
+		sum += digit
+	}
+	return fmt.Sprintf("%s%d", base, (10-sum%10)%10)
+}
+
+// resultsByPair indexa os resultados das três listas pelo par IBM/EAN
+func resultsByPair(t *testing.T, output *dto.ProcessProductsOutput) map[[2]string]dto.ProductResultDTO {
+	t.Helper()
+	results := make(map[[2]string]dto.ProductResultDTO)
+	for _, list := range [][]dto.ProductResultDTO{output.SuccessList, output.FailureList, output.NotProcessedList} {
+		for _, result := range list {
+			key := [2]string{result.IBM, result.EAN}
+			if _, dup := results[key]; dup {
+				t.Errorf("par %v com mais de um resultado", key)
+			}
+			results[key] = result
+		}
+	}
+	return results
+}
+
+// checkTotals confere se os totais batem com as listas e com o número de pares da entrada
+func checkTotals(t *testing.T, output *dto.ProcessProductsOutput, pairs int) {
+	t.Helper()
+	totals := output.Totals
+	if totals.Ok != len(output.SuccessList) || totals.Fail != len(output.FailureList) || totals.NotProcessed != len(output.NotProcessedList) {
+		t.Errorf("totais %+v não batem com as listas (%d ok, %d falhas, %d não processados)",
+			totals, len(output.SuccessList), len(output.FailureList), len(output.NotProcessedList))
+	}
+	if totals.Total() != pairs {
+		t.Errorf("totais somam %d, esperado %d pares", totals.Total(), pairs)
+	}
+}
+
+func TestExecuteWithOptionsLinksPairs(t *testing.T) {
+	f := newTestFixture(
+		map[string]int{"0001002154": 10, "0001002155": 11},
+		map[string]int{testEAN(1): 100, testEAN(2): 200},
+	)
+
+	input := pairsInput(map[string][]string{
+		"0001002154": {testEAN(1), testEAN(2), testEAN(3), "124"},
+		"0001002155": {testEAN(1)},
+		"0001002157": {testEAN(1)},
+	})
+
+	output, err := f.uc.ExecuteWithOptions(context.Background(), input, ExecuteOptions{})
+	if err != nil {
+		t.Fatalf("ExecuteWithOptions: %v", err)
+	}
+	checkTotals(t, output, 6)
+
+	want := map[[2]string]struct{ status, code, relation string }{
+		{"0001002154", testEAN(1)}: {"ok", "", dto.RelationCreated},
+		{"0001002154", testEAN(2)}: {"ok", "", dto.RelationCreated},
+		{"0001002154", testEAN(3)}: {"fail", dto.ReasonEANNotFound, ""},
+		{"0001002154", "124"}:      {"fail", dto.ReasonInvalidEAN, ""},
+		{"0001002155", testEAN(1)}: {"ok", "", dto.RelationCreated},
+		{"0001002157", testEAN(1)}: {"fail", dto.ReasonDealerNotFound, ""},
+	}
+	results := resultsByPair(t, output)
+	for key, expected := range want {
+		result, ok := results[key]
+		if !ok {
+			t.Errorf("par %v sem resultado", key)
+			continue
+		}
+		if result.Status != expected.status || result.ReasonCode != expected.code || result.Relation != expected.relation {
+			t.Errorf("par %v: status %q, motivo %q, relação %q; esperado %+v", key, result.Status, result.ReasonCode, result.Relation, expected)
+		}
+	}
+
+	// IBM sem revendedor traz os cadastrados parecidos
+	if got := results[[2]string{"0001002157", testEAN(1)}].IBMSuggestions; !slices.Equal(got, []string{"0001002154", "0001002155"}) {
+		t.Errorf("SugestoesIBM = %v", got)
+	}
+	if f.queue.sent.Load() != 1 {
+		t.Errorf("mensagem \"mover\" enviada %d vezes, esperado 1", f.queue.sent.Load())
+	}
+
+	// Na segunda execução as relações já existem
+	output, err = f.uc.ExecuteWithOptions(context.Background(), input, ExecuteOptions{})
+	if err != nil {
+		t.Fatalf("ExecuteWithOptions: %v", err)
+	}
+	for _, result := range output.SuccessList {
+		if result.Relation != dto.RelationAlreadyActive {
+			t.Errorf("par %s/%s: relação %q na segunda execução, esperado %q", result.IBM, result.EAN, result.Relation, dto.RelationAlreadyActive)
+		}
+	}
+}
+
+func TestExecuteWithOptionsCancelledTotalsAddUp(t *testing.T) {
+	const stores, eans = 5, 200
+
+	dealers := make(map[string]int, stores)
+	ibmToProducts := make(map[string][]string, stores)
+	products := make(map[string]int, eans)
+	for i := range eans {
+		products[testEAN(i)] = 1000 + i
+	}
+	for s := range stores {
+		ibm := fmt.Sprintf("%010d", s+1)
+		dealers[ibm] = s + 1
+		for i := range eans {
+			ibmToProducts[ibm] = append(ibmToProducts[ibm], testEAN(i))
+		}
+	}
+
+	f := newTestFixture(dealers, products)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Cancela a execução no meio das chamadas da procedure
+	var staged atomic.Int64
+	f.products.onStage = func(ctx context.Context, dealerID, productID int) error {
+		if staged.Add(1) == 150 {
+			cancel()
+		}
+		return nil
+	}
+
+	output, err := f.uc.ExecuteWithOptions(ctx, pairsInput(ibmToProducts), ExecuteOptions{})
+	if err != nil {
+		t.Fatalf("ExecuteWithOptions: %v", err)
+	}
+
+	if !output.Cancelled {
+		t.Error("resultado deveria estar marcado como cancelado")
+	}
+	checkTotals(t, output, stores*eans)
+	if output.Totals.NotProcessed == 0 {
+		t.Error("nenhum par não processado após o cancelamento")
+	}
+
+	results := resultsByPair(t, output)
+	if len(results) != stores*eans {
+		t.Errorf("%d pares distintos nos resultados, esperado %d", len(results), stores*eans)
+	}
+	for _, result := range output.SuccessList {
+		if !f.products.isStaged(*result.DealerID, *result.ProductID) {
+			t.Errorf("par %s/%s reportado como ok sem staging", result.IBM, result.EAN)
+		}
+	}
+	for _, result := range output.NotProcessedList {
+		if result.ReasonCode != dto.ReasonCancelled {
+			t.Errorf("par %s/%s não processado com motivo %q", result.IBM, result.EAN, result.ReasonCode)
+		}
+	}
+}
+
+func TestExecuteWithOptionsRetriesTransientErrors(t *testing.T) {
+	f := newTestFixture(map[string]int{"0000000001": 1}, map[string]int{testEAN(1): 100, testEAN(2): 200, testEAN(3): 300})
+
+	// A primeira chamada do produto 100 cai com erro transitório, o produto 200 falha de vez
+	// e a conexão do produto 300 cai depois que a procedure já gravou o registro
+	var failed sync.Map
+	f.products.onStage = func(ctx context.Context, dealerID, productID int) error {
+		if productID == 200 {
+			return errors.New("ORA-20001: produto bloqueado para integração")
+		}
+		if _, seen := failed.LoadOrStore(productID, true); productID == 100 && !seen {
+			return errors.New("ORA-03113: end-of-file on communication channel")
+		}
+		return nil
+	}
+	var dropped sync.Map
+	f.products.afterStage = func(ctx context.Context, dealerID, productID int) error {
+		if _, seen := dropped.LoadOrStore(productID, true); productID == 300 && !seen {
+			return errors.New("ORA-03113: end-of-file on communication channel")
+		}
+		return nil
+	}
+
+	output, err := f.uc.ExecuteWithOptions(context.Background(), pairsInput(map[string][]string{"0000000001": {testEAN(1), testEAN(2), testEAN(3)}}), ExecuteOptions{})
+	if err != nil {
+		t.Fatalf("ExecuteWithOptions: %v", err)
+	}
+	checkTotals(t, output, 3)
+
+	results := resultsByPair(t, output)
+	retried := results[[2]string{"0000000001", testEAN(1)}]
+	if retried.Status != "ok" || retried.Attempts != 2 {
+		t.Errorf("par com erro transitório: status %q, tentativas %d; esperado ok com 2 tentativas", retried.Status, retried.Attempts)
+	}
+	permanent := results[[2]string{"0000000001", testEAN(2)}]
+	if permanent.Status != "fail" || permanent.ReasonCode != dto.ReasonStagingSPError || permanent.DBCode != "ORA-20001" || permanent.Attempts != 0 {
+		t.Errorf("par com erro permanente: %+v", permanent)
+	}
+
+	// A procedure não é chamada de novo quando o registro já foi gravado: 2 chamadas do
+	// produto 100 e 1 de cada um dos demais
+	committed := results[[2]string{"0000000001", testEAN(3)}]
+	if committed.Status != "ok" || committed.Attempts != 2 {
+		t.Errorf("par gravado antes da queda da conexão: status %q, tentativas %d; esperado ok com 2 tentativas", committed.Status, committed.Attempts)
+	}
+	if calls := f.products.calls.Load(); calls != 4 {
+		t.Errorf("procedure chamada %d vezes, esperado 4", calls)
+	}
+}
+
+func TestExecuteWithOptionsMissingRelationStatus(t *testing.T) {
+	f := newTestFixture(map[string]int{"0000000001": 1}, map[string]int{testEAN(1): 100, testEAN(2): 200})
+	f.productDealer.dropLast = true
+
+	output, err := f.uc.ExecuteWithOptions(context.Background(), pairsInput(map[string][]string{"0000000001": {testEAN(1), testEAN(2)}}), ExecuteOptions{})
+	if err != nil {
+		t.Fatalf("ExecuteWithOptions: %v", err)
+	}
+	checkTotals(t, output, 2)
+
+	// Um par sem resultado no batch não pode ser reportado como relação criada
+	if output.Totals.Ok != 1 || output.Totals.Fail != 1 {
+		t.Fatalf("totais %+v, esperado 1 ok e 1 falha", output.Totals)
+	}
+	failure := output.FailureList[0]
+	if failure.ReasonCode != dto.ReasonRelationCheckError || failure.Relation != "" {
+		t.Errorf("falha %+v, esperado %s sem relação", failure, dto.ReasonRelationCheckError)
+	}
+	if f.products.calls.Load() != 1 {
+		t.Errorf("procedure chamada %d vezes, esperado 1", f.products.calls.Load())
+	}
+}
+
+func TestExecuteWithOptionsUnlink(t *testing.T) {
+	tests := []struct {
+		strategy UnlinkStrategy
+		want     map[string]string // EAN → Relacao
+		remains  map[[2]int]bool
+	}{
+		{
+			strategy: UnlinkDeactivate,
+			want:     map[string]string{testEAN(1): dto.RelationDeactivated, testEAN(2): dto.RelationAlreadyInactive, testEAN(3): dto.RelationNotFound},
+			remains:  map[[2]int]bool{{100, 1}: false, {200, 1}: false, {100, 2}: true},
+		},
+		{
+			strategy: UnlinkDelete,
+			want:     map[string]string{testEAN(1): dto.RelationDeleted, testEAN(2): dto.RelationDeleted, testEAN(3): dto.RelationNotFound},
+			remains:  map[[2]int]bool{{100, 2}: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.strategy), func(t *testing.T) {
+			f := newTestFixture(
+				map[string]int{"0000000001": 1, "0000000002": 2},
+				map[string]int{testEAN(1): 100, testEAN(2): 200, testEAN(3): 300},
+			)
+			f.uc.SetUnlinkStrategy(tt.strategy)
+			f.productDealer.relations = map[[2]int]bool{{100, 1}: true, {200, 1}: false, {100, 2}: true}
+
+			input := pairsInput(map[string][]string{"0000000001": {testEAN(1), testEAN(2), testEAN(3)}})
+			output, err := f.uc.ExecuteWithOptions(context.Background(), input, ExecuteOptions{Mode: ModeUnlink})
+			if err != nil {
+				t.Fatalf("ExecuteWithOptions: %v", err)
+			}
+			checkTotals(t, output, 3)
+
+			results := resultsByPair(t, output)
+			for ean, relation := range tt.want {
+				if got := results[[2]string{"0000000001", ean}].Relation; got != relation {
+					t.Errorf("EAN %s: relação %q, esperado %q", ean, got, relation)
+				}
+			}
+			if got := results[[2]string{"0000000001", testEAN(3)}]; got.Status != "fail" || got.ReasonCode != dto.ReasonRelationNotFound {
+				t.Errorf("par sem relação: status %q, motivo %q", got.Status, got.ReasonCode)
+			}
+
+			// A relação de outra loja com o mesmo produto não é afetada
+			if !maps.Equal(f.productDealer.relations, tt.remains) {
+				t.Errorf("relações após a remoção = %v, esperado %v", f.productDealer.relations, tt.remains)
+			}
+			if !f.products.isStaged(1, 100) {
+				t.Error("par removido sem staging: a integração não propagaria a remoção")
+			}
+		})
+	}
+}
+
+func TestExecuteWithOptionsJournal(t *testing.T) {
+	f := newTestFixture(map[string]int{"0000000001": 1}, map[string]int{testEAN(1): 100, testEAN(2): 200, testEAN(3): 300})
+	input := pairsInput(map[string][]string{"0000000001": {testEAN(1), testEAN(2), testEAN(3)}})
+
+	dealerID, productID := 1, 100
+	journal := &fakeJournal{
+		header: &dto.CheckpointHeader{Mode: string(ModeLink)},
+		entries: []dto.CheckpointEntry{
+			// Concluído: não é reprocessado
+			{IBM: "0000000001", EAN: testEAN(1), Result: dto.ProductResultDTO{DealerID: &dealerID, ProductID: &productID, IBM: "0000000001", EAN: testEAN(1), Status: "ok"}},
+			// Falha transitória de uma versão anterior: volta ao pipeline
+			{IBM: "0000000001", EAN: testEAN(2), Result: dto.ProductResultDTO{IBM: "0000000001", EAN: testEAN(2), Status: "fail", ReasonCode: dto.ReasonStagingSPError, DBError: "ORA-03113: end-of-file on communication channel"}},
+		},
+	}
+
+	output, err := f.uc.ExecuteWithOptions(context.Background(), input, ExecuteOptions{Journal: journal})
+	if err != nil {
+		t.Fatalf("ExecuteWithOptions: %v", err)
+	}
+	checkTotals(t, output, 3)
+	if output.Totals.Ok != 3 {
+		t.Errorf("totais %+v, esperado 3 ok", output.Totals)
+	}
+	if f.products.calls.Load() != 2 {
+		t.Errorf("procedure chamada %d vezes, esperado 2 (par concluído reaproveitado)", f.products.calls.Load())
+	}
+	if len(journal.written) != 2 {
+		t.Errorf("%d entradas gravadas no journal, esperado 2", len(journal.written))
+	}
+
+	// Retomar com outro modo é recusado
+	_, err = f.uc.ExecuteWithOptions(context.Background(), input, ExecuteOptions{Journal: journal, Mode: ModeUnlink})
+	if err == nil {
+		t.Error("retomada no modo unlink de um journal do modo link deveria falhar")
+	}
+}
+
+func TestExecuteWithOptionsResumedSyncKeepsCompletedPairs(t *testing.T) {
+	f := newTestFixture(map[string]int{"0000000001": 1}, map[string]int{testEAN(1): 10, testEAN(2): 20, testEAN(3): 30})
+	f.uc.SetSyncMaxRemoval(50)
+	f.productDealer.relations = map[[2]int]bool{{10, 1}: true, {20, 1}: true, {30, 1}: true}
+
+	// O par do EAN 1 foi concluído antes da interrupção e continua na entrada
+	dealerID, productID := 1, 10
+	journal := &fakeJournal{
+		header: &dto.CheckpointHeader{Mode: string(ModeSync), UnlinkStrategy: string(UnlinkDeactivate)},
+		entries: []dto.CheckpointEntry{
+			{IBM: "0000000001", EAN: testEAN(1), Result: dto.ProductResultDTO{DealerID: &dealerID, ProductID: &productID, IBM: "0000000001", EAN: testEAN(1), Status: "ok", Relation: dto.RelationAlreadyActive}},
+		},
+	}
+
+	input := pairsInput(map[string][]string{"0000000001": {testEAN(1), testEAN(2)}})
+	output, err := f.uc.ExecuteWithOptions(context.Background(), input, ExecuteOptions{Mode: ModeSync, Journal: journal})
+	if err != nil {
+		t.Fatalf("ExecuteWithOptions: %v", err)
+	}
+	checkTotals(t, output, 3)
+
+	// Somente o produto ausente da entrada é removido
+	want := map[[2]int]bool{{10, 1}: true, {20, 1}: true, {30, 1}: false}
+	if !maps.Equal(f.productDealer.relations, want) {
+		t.Errorf("relações após a sincronização retomada = %v, esperado %v", f.productDealer.relations, want)
+	}
+	removed := 0
+	for _, result := range output.SuccessList {
+		if result.SyncRemoval != (result.Relation == dto.RelationDeactivated) {
+			t.Errorf("produto %d com relação %q e RemocaoSincronizacao %v", *result.ProductID, result.Relation, result.SyncRemoval)
+		}
+		if result.Relation == dto.RelationDeactivated {
+			removed++
+			if *result.ProductID != 30 {
+				t.Errorf("produto %d desativado, esperado 30", *result.ProductID)
+			}
+		}
+	}
+	if removed != 1 {
+		t.Errorf("%d relações desativadas, esperado 1", removed)
+	}
+}
+
+func TestExecuteWithOptionsDryRunIgnoresJournal(t *testing.T) {
+	f := newTestFixture(map[string]int{"0000000001": 1}, map[string]int{testEAN(1): 100, testEAN(2): 200})
+	input := pairsInput(map[string][]string{"0000000001": {testEAN(1), testEAN(2)}})
+
+	// Journal de uma execução real interrompida, sem cabeçalho
+	journal := &fakeJournal{entries: []dto.CheckpointEntry{
+		{IBM: "0000000001", EAN: testEAN(1), Result: dto.ProductResultDTO{IBM: "0000000001", EAN: testEAN(1), Status: "ok"}},
+	}}
+
+	output, err := f.uc.ExecuteWithOptions(context.Background(), input, ExecuteOptions{DryRun: true, Journal: journal})
+	if err != nil {
+		t.Fatalf("ExecuteWithOptions: %v", err)
+	}
+	checkTotals(t, output, 2)
+	if !output.DryRun || output.Totals.Ok != 2 {
+		t.Errorf("simulação %v com totais %+v, esperado 2 ok", output.DryRun, output.Totals)
+	}
+
+	// O par concluído no journal também é planejado, e nada é gravado
+	for _, result := range output.SuccessList {
+		if len(result.Planned) == 0 {
+			t.Errorf("par %s/%s sem ações planejadas", result.IBM, result.EAN)
+		}
+	}
+	if journal.header != nil || len(journal.written) != 0 {
+		t.Errorf("simulação gravou no journal: cabeçalho %v, %d entradas", journal.header, len(journal.written))
+	}
+	if f.products.calls.Load() != 0 || len(f.productDealer.relations) != 0 || f.queue.sent.Load() != 0 {
+		t.Errorf("simulação gravou no banco: %d chamadas da procedure, %d relações, %d mensagens",
+			f.products.calls.Load(), len(f.productDealer.relations), f.queue.sent.Load())
+	}
+}
+
+func TestJournaled(t *testing.T) {
+	tests := []struct {
+		name   string
+		result dto.ProductResultDTO
+		want   bool
+	}{
+		{name: "sucesso", result: dto.ProductResultDTO{Status: "ok"}, want: true},
+		{name: "falha de cadastro", result: dto.ProductResultDTO{Status: "fail", ReasonCode: dto.ReasonEANNotFound}, want: true},
+		{name: "falha permanente do banco", result: dto.ProductResultDTO{Status: "fail", DBError: "ORA-00001: unique constraint violated"}, want: true},
+		{name: "falha transitória do banco", result: dto.ProductResultDTO{Status: "fail", DBError: "ORA-03135: connection lost contact"}, want: false},
+		{name: "não processado", result: dto.ProductResultDTO{Status: statusNotProcessed}, want: false},
+	}
+
+	for _, tt := range tests {
+		if got := journaled(tt.result); got != tt.want {
+			t.Errorf("%s: journaled = %v, esperado %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMergeSorted(t *testing.T) {
+	tests := []struct {
+		a, b, want []string
+	}{
+		{nil, nil, nil},
+		{nil, []string{"a", "b"}, []string{"a", "b"}},
+		{[]string{"a", "b"}, nil, []string{"a", "b"}},
+		{[]string{"a", "c"}, []string{"b", "d"}, []string{"a", "b", "c", "d"}},
+		{[]string{"a", "b", "c"}, []string{"b", "c", "d"}, []string{"a", "b", "c", "d"}},
+		{[]string{"b"}, []string{"a", "b", "c"}, []string{"a", "b", "c"}},
+	}
+
+	for _, tt := range tests {
+		if got := mergeSorted(tt.a, tt.b); !slices.Equal(got, tt.want) {
+			t.Errorf("mergeSorted(%v, %v) = %v, esperado %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,748 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.thiagohmm.com.br/cargaparcial/domain/entities"
+	"github.thiagohmm.com.br/cargaparcial/usecase/dto"
+)
+
+// statusNotProcessed identifica pares não processados por cancelamento
+const statusNotProcessed = "not_processed"
+
+// relationFlushInterval é o intervalo máximo que um batch parcial de relações aguarda antes do flush
+const relationFlushInterval = 500 * time.Millisecond
+
+// processRun guarda o estado de uma única execução do use case, permitindo
+// que várias execuções rodem em paralelo sobre a mesma instância
+type processRun struct {
+	uc   *ProcessProductsUseCase
+	opts ExecuteOptions
+
+	// Produtos ativos por IdRevendedor antes da sincronização (somente leitura, modo sync)
+	syncActive map[int]map[int]bool
+
+	// Pares concluídos em execuções anteriores (retomada via journal)
+	completed map[pairKey]dto.ProductResultDTO
+
+	// Produtos por EAN, resolvidos antes do despacho dos jobs (somente leitura)
+	productsByEAN map[string][]entities.Product
+
+	// IBMs cadastrados, consultados uma vez por execução para sugerir IBMs parecidos
+	registeredIBMs []string
+
+	// Progresso
+	processedItems  int64
+	startTime       time.Time
+	lastProgressLog int64 // UnixNano do último log de progresso
+
+	// Totais acumulados entre os blocos processados (usados apenas pelo coletor e pelo despacho)
+	plannedJobs    int
+	collected      int
+	dispatchedJobs int
+	journalErrors  int
+	sinkErrors     int
+
+	// Novas tentativas por erro transitório, somadas entre os workers (limitadas por RetryPolicy.Budget)
+	retries              atomic.Int64
+	retryBudgetExhausted atomic.Bool
+}
+
+// resolvedPair é um par com produto e revendedor já resolvidos, aguardando
+// a relação ProdutoRevendedor e a chamada da procedure de staging
+type resolvedPair struct {
+	job        JobInput
+	productIDs []int                     // produtos a vincular (mais de um quando a política vincula todos os candidatos)
+	candidates []int                     // candidatos de um EAN ambíguo (nil quando o EAN tem um único produto)
+	remove     bool                      // a relação deve ser removida (modo unlink ou sobra da sincronização)
+	relations  []entities.RelationStatus // resultado da relação de cada produto, alinhado com productIDs
+	planned    []string                  // ações planejadas (somente em simulação)
+	attempts   int                       // maior número de tentativas de uma operação de banco do par (0 sem novas tentativas)
+}
+
+// recordAttempts registra as tentativas de uma operação de banco do par
+func (p *resolvedPair) recordAttempts(attempts int) {
+	if attempts > 1 && attempts > p.attempts {
+		p.attempts = attempts
+	}
+}
+
+// newProcessRun cria o estado de uma nova execução. Falha se o journal informado
+// foi gravado por uma execução com outro modo de operação.
+func (uc *ProcessProductsUseCase) newProcessRun(opts ExecuteOptions) (*processRun, error) {
+	// Uma simulação não pode marcar pares como concluídos nem reaproveitar resultados reais
+	if opts.DryRun {
+		if opts.Journal != nil {
+			log.Println("🧪 Simulação: journal de checkpoint ignorado")
+		}
+		opts.Journal = nil
+	}
+
+	run := &processRun{uc: uc, opts: opts}
+	if opts.Journal != nil {
+		if err := prepareJournal(opts.Journal, uc.checkpointHeader(run.mode())); err != nil {
+			return nil, err
+		}
+	}
+
+	completed := completedPairs(opts.Journal)
+	if len(completed) > 0 {
+		log.Printf("♻️  Retomando execução: %d pares já concluídos no journal", len(completed))
+	}
+
+	now := time.Now()
+	run.completed = completed
+	run.startTime = now
+	run.lastProgressLog = now.UnixNano()
+	return run, nil
+}
+
+// mode retorna o modo de operação da execução (link quando não informado)
+func (r *processRun) mode() OperationMode {
+	if r.opts.Mode == "" {
+		return ModeLink
+	}
+	return r.opts.Mode
+}
+
+// applyRelations grava (ou, em simulação, apenas planeja) as relações a vincular
+// do batch, retornando o resultado de cada uma
+func (r *processRun) applyRelations(ctx context.Context, productDealers []*entities.ProductDealer) ([]entities.RelationStatus, error) {
+	if len(productDealers) == 0 {
+		return nil, nil
+	}
+	if r.opts.DryRun {
+		// Em simulação apenas o estado atual das relações é consultado
+		return r.uc.productDealerRepo.PlanBatch(ctx, productDealers)
+	}
+	return r.uc.productDealerRepo.EnsureBatch(ctx, productDealers)
+}
+
+// removeRelations desativa ou exclui (ou, em simulação, apenas planeja a remoção)
+// as relações a remover do batch, conforme a UnlinkStrategy
+func (r *processRun) removeRelations(ctx context.Context, productDealers []*entities.ProductDealer) ([]entities.RelationStatus, error) {
+	if len(productDealers) == 0 {
+		return nil, nil
+	}
+	repo := r.uc.productDealerRepo
+	switch {
+	case r.opts.DryRun:
+		statuses, err := repo.PlanBatch(ctx, productDealers)
+		if err != nil {
+			return nil, err
+		}
+		return r.uc.unlinkStrategy.planUnlink(statuses), nil
+	case r.uc.unlinkStrategy == UnlinkDelete:
+		return repo.DeleteBatch(ctx, productDealers)
+	default:
+		return repo.DeactivateBatch(ctx, productDealers)
+	}
+}
+
+// sink retorna o destino dos resultados da execução: o Sink das opções, quando
+// informado, e as listas do output (sem Sink ou com KeepResults)
+func (r *processRun) sink(output *dto.ProcessProductsOutput) ResultSink {
+	if r.opts.DiscardsResults() {
+		return MultiSink(r.opts.Sink)
+	}
+	return MultiSink(memorySink{output: output}, r.opts.Sink)
+}
+
+// recordProcessed incrementa o contador da execução e registra o progresso
+func (r *processRun) recordProcessed() {
+	r.logProgress(atomic.AddInt64(&r.processedItems, 1))
+}
+
+// logProgress registra o progresso a cada 5 segundos (apenas um chamador loga por intervalo)
+func (r *processRun) logProgress(total int64) {
+	last := atomic.LoadInt64(&r.lastProgressLog)
+	now := time.Now().UnixNano()
+	if time.Duration(now-last) < 5*time.Second {
+		return
+	}
+	if !atomic.CompareAndSwapInt64(&r.lastProgressLog, last, now) {
+		return
+	}
+
+	elapsed := time.Since(r.startTime).Seconds()
+	rate := float64(total) / elapsed
+	log.Printf("⚡ Progresso: %d itens | %.0f items/seg | Tempo: %.1fs", total, rate, elapsed)
+}
+
+// resolveWorker resolve o produto pelo EAN no mapa pré-carregado.
+// Falhas vão direto para results; pares resolvidos seguem para o estágio de relações.
+func (r *processRun) resolveWorker(ctx context.Context, id int, jobs <-chan JobInput, relations chan<- resolvedPair, results chan<- jobResult, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	processedCount := 0
+	for job := range jobs {
+		// Após o cancelamento os jobs restantes no canal são apenas drenados
+		if ctx.Err() != nil {
+			results <- jobResult{job: job, result: notProcessedResult(job)}
+			continue
+		}
+
+		pair, failure := r.resolvePair(job)
+		processedCount++
+		if failure != nil {
+			results <- jobResult{job: job, result: *failure}
+			continue
+		}
+
+		// Na sincronização, pares já vinculados não são regravados nem reenviados ao staging
+		if r.unchanged(pair) {
+			results <- jobResult{job: job, result: unchangedResult(pair, r.opts.DryRun)}
+			continue
+		}
+
+		relations <- pair
+	}
+
+	log.Printf("Worker de resolução %d finalizado: processou %d itens no total", id, processedCount)
+}
+
+// resolvePair valida o EAN e busca seu produto no mapa resolvido em lote
+func (r *processRun) resolvePair(job JobInput) (resolvedPair, *dto.ProductResultDTO) {
+	dealerID := job.Dealer.ID
+
+	ean, err := r.uc.gtinOptions.Normalize(job.ProductCode)
+	if err != nil {
+		return resolvedPair{}, &dto.ProductResultDTO{
+			DealerID:   &dealerID,
+			ProductID:  nil,
+			EAN:        job.ProductCode,
+			Status:     "fail",
+			Reason:     "EAN inválido: " + err.Error(),
+			ReasonCode: dto.ReasonInvalidEAN,
+		}
+	}
+
+	products := r.productsByEAN[ean]
+	if len(products) == 0 {
+		return resolvedPair{}, &dto.ProductResultDTO{
+			DealerID:   &dealerID,
+			ProductID:  nil,
+			EAN:        job.ProductCode,
+			Status:     "fail",
+			Reason:     "Produto não encontrado pelo EAN",
+			ReasonCode: dto.ReasonEANNotFound,
+		}
+	}
+
+	// EAN associado a mais de um produto: aplicar a política configurada
+	productIDs, candidates := r.uc.ambiguityPolicy.selectProducts(products)
+	if len(productIDs) == 0 {
+		return resolvedPair{}, &dto.ProductResultDTO{
+			DealerID:   &dealerID,
+			ProductID:  nil,
+			EAN:        job.ProductCode,
+			Status:     "fail",
+			Reason:     "EAN associado a mais de um produto",
+			ReasonCode: dto.ReasonAmbiguousEAN,
+			Candidates: candidates,
+		}
+	}
+
+	return resolvedPair{
+		job:        job,
+		productIDs: productIDs,
+		candidates: candidates,
+		remove:     r.mode() == ModeUnlink,
+	}, nil
+}
+
+// relationStage agrupa os pares em batches e garante a relação ProdutoRevendedor
+// de cada um com um upsert idempotente (cria, reativa ou mantém a existente) ou,
+// para pares a remover, a desativa ou exclui. Pares sem relação a remover são
+// reportados como falha sem passar pelo staging. Um par só segue para o staging
+// depois que o batch que contém sua relação foi gravado; se a gravação falhar,
+// os pares afetados são reportados como falha.
+func (r *processRun) relationStage(ctx context.Context, relations <-chan resolvedPair, staging chan<- resolvedPair, results chan<- jobResult) {
+	batch := newRelationBatch(r.uc.batchSize)
+
+	flush := func() {
+		if batch.empty() {
+			return
+		}
+
+		// Após o cancelamento, as relações já enfileiradas ainda são gravadas
+		// com um contexto próprio
+		flushCtx := ctx
+		if ctx.Err() != nil {
+			var cancel context.CancelFunc
+			flushCtx, cancel = context.WithTimeout(context.WithoutCancel(ctx), time.Minute)
+			defer cancel()
+		}
+
+		// Vinculações e remoções são gravadas separadamente: a falha de uma
+		// não invalida os pares da outra
+		var linked, removed []entities.RelationStatus
+		linkAttempts, linkErr := r.withRetry(flushCtx, "batch de relações", func(ctx context.Context) (err error) {
+			linked, err = r.applyRelations(ctx, batch.productDealers)
+			return err
+		})
+		if linkErr != nil {
+			log.Printf("Erro ao criar batch de ProductDealers (%d relações afetadas): %v", len(batch.productDealers), linkErr)
+		}
+		removeAttempts, removeErr := r.withRetry(flushCtx, "remoção do batch de relações", func(ctx context.Context) (err error) {
+			removed, err = r.removeRelations(ctx, batch.removals)
+			return err
+		})
+		if removeErr != nil {
+			log.Printf("Erro ao remover batch de ProductDealers (%d relações afetadas): %v", len(batch.removals), removeErr)
+		}
+
+		statuses := append(linked, removed...)
+		if len(statuses) > 0 {
+			logRelationStatuses(statuses, r.opts.DryRun)
+		}
+		batch.annotate(linked, removed, r.opts.DryRun)
+
+		for _, pair := range batch.pairs {
+			err, attempts := linkErr, linkAttempts
+			if pair.remove {
+				err, attempts = removeErr, removeAttempts
+			}
+			if err == nil && slices.Contains(pair.relations, entities.RelationUnknown) {
+				err = errRelationStatusMissing
+			}
+			pair.recordAttempts(attempts)
+			if err != nil {
+				result := failedRelationResult(pair, err)
+				if ctx.Err() != nil {
+					result = notProcessedResult(pair.job)
+				}
+				results <- jobResult{job: pair.job, result: result}
+				continue
+			}
+
+			// Sem relação a remover não há o que propagar para a integração
+			if pair.notFound() {
+				results <- jobResult{job: pair.job, result: relationNotFoundResult(pair)}
+				continue
+			}
+			staging <- pair
+		}
+
+		batch.reset()
+	}
+
+	ticker := time.NewTicker(relationFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case pair, ok := <-relations:
+			if !ok {
+				// Flush final
+				flush()
+				return
+			}
+
+			batch.add(pair)
+			if batch.full() {
+				flush()
+			}
+
+		case <-ticker.C:
+			// Evita que pares de um batch parcial fiquem parados aguardando novos itens
+			flush()
+		}
+	}
+}
+
+// stagingWorker grava a integração no staging para pares com relação confirmada
+func (r *processRun) stagingWorker(ctx context.Context, id int, staging <-chan resolvedPair, results chan<- jobResult, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	processedCount := 0
+	for pair := range staging {
+		if ctx.Err() != nil {
+			results <- jobResult{job: pair.job, result: notProcessedResult(pair.job)}
+			continue
+		}
+
+		var result dto.ProductResultDTO
+		if r.opts.DryRun {
+			result = plannedResult(pair)
+		} else {
+			result = r.stagePair(ctx, pair)
+		}
+		if result.Status != "ok" && ctx.Err() != nil {
+			result = notProcessedResult(pair.job)
+		}
+		results <- jobResult{job: pair.job, result: result}
+		processedCount++
+	}
+
+	log.Printf("Worker de staging %d finalizado: processou %d itens no total", id, processedCount)
+}
+
+// stagePair chama a procedure de staging para cada produto do par e confirma a gravação
+func (r *processRun) stagePair(ctx context.Context, pair resolvedPair) dto.ProductResultDTO {
+	dealerID := pair.job.Dealer.ID
+
+	for _, productID := range pair.productIDs {
+		attempts, failure := r.stageProduct(ctx, dealerID, productID)
+		pair.recordAttempts(attempts)
+		if failure != nil {
+			return withDBError(dto.ProductResultDTO{
+				DealerID:   &dealerID,
+				ProductID:  &productID,
+				Status:     "fail",
+				Reason:     failure.reason,
+				ReasonCode: failure.code,
+				Candidates: pair.candidates,
+				Relation:   relationLabel(pair.relations[0]),
+				Attempts:   pair.attempts,
+			}, failure.err)
+		}
+	}
+
+	productID := pair.productIDs[0]
+	return dto.ProductResultDTO{
+		DealerID:   &dealerID,
+		ProductID:  &productID,
+		Status:     "ok",
+		Candidates: pair.candidates,
+		Relation:   relationLabel(pair.relations[0]),
+		Attempts:   pair.attempts,
+	}
+}
+
+// stageFailure descreve por que a gravação de um produto no staging falhou
+type stageFailure struct {
+	code   string
+	reason string
+	err    error // Erro do banco, quando houver
+}
+
+// stageProduct chama a procedure de staging e confirma a gravação do registro,
+// repetindo as chamadas que falham por erro transitório. Retorna o maior número de
+// tentativas de uma das chamadas e a falha (nil em caso de sucesso).
+func (r *processRun) stageProduct(ctx context.Context, dealerID, productID int) (int, *stageFailure) {
+	// Gravar integração produto staging (chama a stored procedure). A chamada não é
+	// idempotente e, se a conexão cair, a procedure pode ter gravado o registro antes do
+	// erro: a nova tentativa só chama a procedure se o registro ainda não existir.
+	called := false
+	saveAttempts, err := r.withRetry(ctx, "procedure de staging", func(ctx context.Context) error {
+		if called {
+			staging, err := r.uc.productIntegrationRepo.GetByProductAndDealer(ctx, productID, dealerID)
+			if err != nil {
+				return err
+			}
+			if staging != nil {
+				return nil
+			}
+		}
+		called = true
+		return r.uc.productRepo.SaveIntegrationStaging(ctx, dealerID, productID)
+	})
+	if err != nil {
+		log.Printf("Erro ao gravar integração produto staging: %v", err)
+		return saveAttempts, &stageFailure{code: dto.ReasonStagingSPError, reason: "Erro ao gravar integração produto staging", err: err}
+	}
+
+	// Verificar se o registro foi realmente inserido na tabela IntegracaoProdutoStaging
+	// (igual ao código TypeScript que faz productIntegrationStagingQuery.getByProductIntegrationStaging)
+	var staging *entities.ProductIntegrationStaging
+	checkAttempts, err := r.withRetry(ctx, "verificação do staging", func(ctx context.Context) (err error) {
+		staging, err = r.uc.productIntegrationRepo.GetByProductAndDealer(ctx, productID, dealerID)
+		return err
+	})
+	attempts := max(saveAttempts, checkAttempts)
+	if err != nil {
+		log.Printf("Erro ao verificar ProductIntegrationStaging: %v", err)
+		return attempts, &stageFailure{code: dto.ReasonStagingCheckError, reason: "Erro ao verificar integração produto staging", err: err}
+	}
+
+	// Se o registro existe, retorna sucesso. Caso contrário, falha.
+	if staging == nil {
+		return attempts, &stageFailure{code: dto.ReasonStagingNotVisible, reason: "Registro não encontrado após chamada da procedure"}
+	}
+
+	return attempts, nil
+}
+
+// relationBatch acumula os pares de um batch de ProductDealers, separando as
+// relações a vincular das relações a remover.
+// Pares repetidos (linhas duplicadas na entrada) compartilham a mesma relação.
+type relationBatch struct {
+	size           int
+	pairs          []resolvedPair
+	productDealers []*entities.ProductDealer
+	removals       []*entities.ProductDealer
+	seen           map[[2]int]bool
+}
+
+// newRelationBatch cria um batch vazio com o tamanho informado
+func newRelationBatch(size int) *relationBatch {
+	b := &relationBatch{size: size}
+	b.reset()
+	return b
+}
+
+// add adiciona um par ao batch
+func (b *relationBatch) add(pair resolvedPair) {
+	b.pairs = append(b.pairs, pair)
+
+	for _, productID := range pair.productIDs {
+		key := [2]int{productID, pair.job.Dealer.ID}
+		if b.seen[key] {
+			continue
+		}
+		b.seen[key] = true
+		pd := &entities.ProductDealer{
+			ProductID: productID,
+			DealerID:  pair.job.Dealer.ID,
+			IsActive:  !pair.remove,
+		}
+		if pair.remove {
+			b.removals = append(b.removals, pd)
+		} else {
+			b.productDealers = append(b.productDealers, pd)
+		}
+	}
+}
+
+// full indica se o batch atingiu o tamanho de flush
+func (b *relationBatch) full() bool {
+	return len(b.productDealers)+len(b.removals) >= b.size
+}
+
+// empty indica se o batch não possui pares
+func (b *relationBatch) empty() bool {
+	return len(b.pairs) == 0
+}
+
+// errRelationStatusMissing indica que o repositório não retornou o resultado de uma relação do batch
+var errRelationStatusMissing = errors.New("resultado da relação produto-revendedor não retornado pelo repositório")
+
+// annotate registra em cada par o resultado das relações de seus produtos e, em
+// simulação, as ações planejadas. linked é alinhado com productDealers e removed
+// com removals; ficam vazios quando a gravação correspondente falhou. Um produto
+// sem resultado fica com RelationUnknown e o par é tratado como falha.
+func (b *relationBatch) annotate(linked, removed []entities.RelationStatus, dryRun bool) {
+	byKey := make(map[[2]int]entities.RelationStatus, len(linked)+len(removed))
+	for i, status := range linked {
+		byKey[[2]int{b.productDealers[i].ProductID, b.productDealers[i].DealerID}] = status
+	}
+	for i, status := range removed {
+		byKey[[2]int{b.removals[i].ProductID, b.removals[i].DealerID}] = status
+	}
+
+	for i := range b.pairs {
+		pair := &b.pairs[i]
+		pair.relations = make([]entities.RelationStatus, len(pair.productIDs))
+		pair.planned = nil
+		for j, productID := range pair.productIDs {
+			status, ok := byKey[[2]int{productID, pair.job.Dealer.ID}]
+			if !ok {
+				status = entities.RelationUnknown
+			}
+			pair.relations[j] = status
+			if !dryRun {
+				continue
+			}
+			if action := plannedAction(pair.relations[j]); action != "" && !slices.Contains(pair.planned, action) {
+				pair.planned = append(pair.planned, action)
+			}
+		}
+		if dryRun && !pair.notFound() {
+			pair.planned = append(pair.planned, dto.ActionStage)
+		}
+	}
+}
+
+// reset limpa o batch após o flush
+func (b *relationBatch) reset() {
+	b.pairs = make([]resolvedPair, 0, b.size)
+	b.productDealers = make([]*entities.ProductDealer, 0, b.size)
+	b.removals = nil
+	b.seen = make(map[[2]int]bool, b.size)
+}
+
+// relationStatusLabels descreve cada resultado de relação nos logs de batch,
+// na ordem em que os totais são exibidos
+var relationStatusLabels = []struct {
+	status  entities.RelationStatus
+	done    string
+	planned string
+}{
+	{entities.RelationCreated, "criados", "a criar"},
+	{entities.RelationReactivated, "reativados", "a reativar"},
+	{entities.RelationAlreadyActive, "já existentes", "já existentes"},
+	{entities.RelationDeactivated, "desativados", "a desativar"},
+	{entities.RelationDeleted, "excluídos", "a excluir"},
+	{entities.RelationAlreadyInactive, "já inativos", "já inativos"},
+	{entities.RelationNotFound, "não encontrados", "não encontrados"},
+}
+
+// logRelationStatuses registra quantas relações do batch foram (ou seriam, em simulação)
+// criadas, reativadas, desativadas, excluídas ou já estavam no estado desejado
+func logRelationStatuses(statuses []entities.RelationStatus, dryRun bool) {
+	counts := make(map[entities.RelationStatus]int)
+	for _, status := range statuses {
+		counts[status]++
+	}
+
+	parts := make([]string, 0, len(counts))
+	for _, label := range relationStatusLabels {
+		if counts[label.status] == 0 {
+			continue
+		}
+		text := label.done
+		if dryRun {
+			text = label.planned
+		}
+		parts = append(parts, fmt.Sprintf("%d %s", counts[label.status], text))
+	}
+
+	if dryRun {
+		log.Printf("🧪 Batch de %d ProductDealers (simulação): %s", len(statuses), strings.Join(parts, ", "))
+		return
+	}
+	log.Printf("🚀 Batch de %d ProductDealers: %s", len(statuses), strings.Join(parts, ", "))
+}
+
+// plannedAction retorna a ação planejada para o resultado de uma relação em simulação
+// (vazio quando não há relação a remover)
+func plannedAction(status entities.RelationStatus) string {
+	switch status {
+	case entities.RelationCreated:
+		return dto.ActionCreateRelation
+	case entities.RelationReactivated:
+		return dto.ActionReactivateRelation
+	case entities.RelationAlreadyActive:
+		return dto.ActionAlreadyLinked
+	case entities.RelationDeactivated:
+		return dto.ActionDeactivateRelation
+	case entities.RelationDeleted:
+		return dto.ActionDeleteRelation
+	case entities.RelationAlreadyInactive:
+		return dto.ActionAlreadyUnlinked
+	default:
+		return ""
+	}
+}
+
+// relationLabel converte o resultado de uma relação no valor do campo Relacao do resultado
+func relationLabel(status entities.RelationStatus) string {
+	switch status {
+	case entities.RelationCreated:
+		return dto.RelationCreated
+	case entities.RelationReactivated:
+		return dto.RelationReactivated
+	case entities.RelationAlreadyActive:
+		return dto.RelationAlreadyActive
+	case entities.RelationDeactivated:
+		return dto.RelationDeactivated
+	case entities.RelationDeleted:
+		return dto.RelationDeleted
+	case entities.RelationAlreadyInactive:
+		return dto.RelationAlreadyInactive
+	case entities.RelationNotFound:
+		return dto.RelationNotFound
+	default:
+		return ""
+	}
+}
+
+// notFound indica se nenhum produto do par tinha relação a remover (modo unlink)
+func (p resolvedPair) notFound() bool {
+	if len(p.relations) == 0 {
+		return false
+	}
+	for _, status := range p.relations {
+		if status != entities.RelationNotFound {
+			return false
+		}
+	}
+	return true
+}
+
+// plannedResult monta o resultado de um par em simulação, com as ações que seriam executadas
+func plannedResult(pair resolvedPair) dto.ProductResultDTO {
+	dealerID := pair.job.Dealer.ID
+	productID := pair.productIDs[0]
+	return dto.ProductResultDTO{
+		DealerID:   &dealerID,
+		ProductID:  &productID,
+		Status:     "ok",
+		Candidates: pair.candidates,
+		Planned:    pair.planned,
+	}
+}
+
+// failedRelationResult monta a falha de um par cujo batch de relações não foi gravado
+func failedRelationResult(pair resolvedPair, err error) dto.ProductResultDTO {
+	dealerID := pair.job.Dealer.ID
+	productID := pair.productIDs[0]
+	reason, code := "Erro ao criar relação produto-revendedor (batch)", dto.ReasonRelationCheckError
+	if pair.remove {
+		reason, code = "Erro ao remover relação produto-revendedor (batch)", dto.ReasonRelationRemoveError
+	}
+	return withDBError(dto.ProductResultDTO{
+		DealerID:   &dealerID,
+		ProductID:  &productID,
+		Status:     "fail",
+		Reason:     reason,
+		ReasonCode: code,
+		Candidates: pair.candidates,
+		Attempts:   pair.attempts,
+	}, err)
+}
+
+// relationNotFoundResult monta a falha de um par sem relação produto-revendedor a remover
+func relationNotFoundResult(pair resolvedPair) dto.ProductResultDTO {
+	dealerID := pair.job.Dealer.ID
+	productID := pair.productIDs[0]
+	return dto.ProductResultDTO{
+		DealerID:   &dealerID,
+		ProductID:  &productID,
+		EAN:        pair.job.ProductCode,
+		Status:     "fail",
+		Reason:     "Relação produto-revendedor não encontrada",
+		ReasonCode: dto.ReasonRelationNotFound,
+		Attempts:   pair.attempts,
+		Candidates: pair.candidates,
+		Relation:   dto.RelationNotFound,
+	}
+}
+
+// dealerNotFoundResult monta a falha de um par cujo IBM não tem revendedor cadastrado
+func dealerNotFoundResult(job JobInput, suggestions []string) dto.ProductResultDTO {
+	return dto.ProductResultDTO{
+		IBM:            job.Dealer.IBM,
+		EAN:            job.ProductCode,
+		Status:         "fail",
+		Reason:         "Revendedor não encontrado",
+		ReasonCode:     dto.ReasonDealerNotFound,
+		IBMSuggestions: suggestions,
+	}
+}
+
+// notProcessedResult monta o resultado de um par que não chegou a ser processado
+func notProcessedResult(job JobInput) dto.ProductResultDTO {
+	result := dto.ProductResultDTO{
+		IBM:         job.Dealer.IBM,
+		EAN:         job.ProductCode,
+		Status:      statusNotProcessed,
+		Reason:      "Processamento cancelado antes da conclusão",
+		ReasonCode:  dto.ReasonCancelled,
+		SyncRemoval: job.Removal,
+	}
+	if job.Dealer.ID != 0 {
+		dealerID := job.Dealer.ID
+		result.DealerID = &dealerID
+	}
+	return result
+}
@@ -0,0 +1,68 @@
+package usecase
+
+import (
+	"errors"
+
+	"github.thiagohmm.com.br/cargaparcial/usecase/dto"
+)
+
+// ResultSink recebe o resultado de cada par assim que ele é concluído.
+// O coletor chama Write de uma única goroutine, na ordem em que os resultados chegam.
+type ResultSink interface {
+	Write(result dto.ProductResultDTO) error
+}
+
+// MultiSink repassa cada resultado a todos os sinks informados (nil é ignorado)
+func MultiSink(sinks ...ResultSink) ResultSink {
+	multi := make(multiSink, 0, len(sinks))
+	for _, sink := range sinks {
+		if sink != nil {
+			multi = append(multi, sink)
+		}
+	}
+	return multi
+}
+
+type multiSink []ResultSink
+
+// Write grava o resultado em todos os sinks, mesmo que algum falhe
+func (m multiSink) Write(result dto.ProductResultDTO) error {
+	var errs []error
+	for _, sink := range m {
+		if err := sink.Write(result); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// memorySink acumula os resultados nas listas de ProcessProductsOutput (arrayOk,
+// arrayFail e arrayNaoProcessado), para a serialização em JSON ao final da execução
+type memorySink struct {
+	output *dto.ProcessProductsOutput
+}
+
+// Write adiciona o resultado à lista correspondente ao seu status
+func (s memorySink) Write(result dto.ProductResultDTO) error {
+	switch result.Status {
+	case "ok":
+		s.output.SuccessList = append(s.output.SuccessList, result)
+	case statusNotProcessed:
+		s.output.NotProcessedList = append(s.output.NotProcessedList, result)
+	default:
+		s.output.FailureList = append(s.output.FailureList, result)
+	}
+	return nil
+}
+
+// countResult atualiza os totais do resultado conforme o status
+func countResult(totals *dto.ResultTotals, result dto.ProductResultDTO) {
+	switch result.Status {
+	case "ok":
+		totals.Ok++
+	case statusNotProcessed:
+		totals.NotProcessed++
+	default:
+		totals.Fail++
+	}
+}
@@ -0,0 +1,270 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"sort"
+
+	"github.thiagohmm.com.br/cargaparcial/usecase/dto"
+)
+
+// DefaultRetryReasons são os códigos de motivo reprocessados por padrão: falhas de
+// banco que podem não se repetir e pares não processados por cancelamento. Falhas
+// de cadastro (EAN_NOT_FOUND, DEALER_NOT_FOUND...) só mudam com correção dos dados.
+var DefaultRetryReasons = []string{
+	dto.ReasonRelationCheckError,
+	dto.ReasonRelationRemoveError,
+	dto.ReasonStagingSPError,
+	dto.ReasonStagingCheckError,
+	dto.ReasonStagingNotVisible,
+	dto.ReasonCancelled,
+}
+
+// RetryOptions configura o reprocessamento das falhas de uma execução anterior
+type RetryOptions struct {
+	// Reasons lista os códigos de motivo (CodigoMotivo) reprocessados.
+	// Vazio usa DefaultRetryReasons.
+	Reasons []string
+}
+
+// RetryPlan descreve os pares de uma execução anterior que serão reprocessados
+type RetryPlan struct {
+	Batches []RetryBatch
+	Skipped int // Falhas sem código de motivo conhecido ou sem IBM/EAN
+	// SyncRemovals conta as remoções da sincronização selecionadas pelo motivo e não
+	// reprocessadas: o EAN do resultado pode não identificar o produto removido
+	SyncRemovals int
+	reasons      []string
+}
+
+// RetryBatch agrupa os pares reprocessados no mesmo modo de operação
+type RetryBatch struct {
+	Mode  OperationMode
+	Input dto.ProcessProductsInput
+	Pairs int
+}
+
+// Pairs retorna o total de pares reprocessados
+func (p RetryPlan) Pairs() int {
+	total := 0
+	for _, batch := range p.Batches {
+		total += batch.Pairs
+	}
+	return total
+}
+
+// PlanRetry seleciona, em arrayFail e arrayNaoProcessado do resultado anterior, os pares
+// com os códigos de motivo informados e monta a entrada de cada modo de operação.
+// Na sincronização, os pares da entrada são refeitos no modo link (reexecutar o sync com
+// apenas parte do sortimento removeria o resto) e as remoções não são reprocessadas: o
+// EAN de uma relação fora da entrada é só o menor código de barras do produto e pode
+// levar a outro produto. Elas são contadas em SyncRemovals e voltam a ser feitas
+// reexecutando a sincronização com o sortimento completo.
+func PlanRetry(previous *dto.ProcessProductsOutput, opts RetryOptions) RetryPlan {
+	reasons := opts.Reasons
+	if len(reasons) == 0 {
+		reasons = DefaultRetryReasons
+	}
+
+	previousMode, err := ParseOperationMode(previous.Mode)
+	if err != nil {
+		previousMode = ModeLink
+	}
+
+	plan := RetryPlan{reasons: reasons}
+	inputs := make(map[OperationMode]*RetryBatch)
+	seen := make(map[OperationMode]map[[2]string]bool)
+
+	for _, result := range retryCandidates(previous) {
+		code := retryReasonCode(result)
+		if code == "" || result.IBM == "" || result.EAN == "" {
+			plan.Skipped++
+			continue
+		}
+		if !slices.Contains(reasons, code) {
+			continue
+		}
+
+		mode := previousMode
+		if result.SyncRemoval || (mode == ModeSync && isRemoval(result)) {
+			plan.SyncRemovals++
+			continue
+		}
+		if mode == ModeSync {
+			mode = ModeLink
+		}
+
+		batch, ok := inputs[mode]
+		if !ok {
+			batch = &RetryBatch{Mode: mode, Input: dto.ProcessProductsInput{
+				IBMToProducts: make(map[string][]string),
+				SourceRows:    make(map[[2]string][]string),
+			}}
+			inputs[mode] = batch
+			seen[mode] = make(map[[2]string]bool)
+		}
+
+		key := [2]string{result.IBM, result.EAN}
+		if seen[mode][key] {
+			continue
+		}
+		seen[mode][key] = true
+
+		if _, ok := batch.Input.IBMToProducts[result.IBM]; !ok {
+			batch.Input.IBMCodes = append(batch.Input.IBMCodes, result.IBM)
+		}
+		batch.Input.IBMToProducts[result.IBM] = append(batch.Input.IBMToProducts[result.IBM], result.EAN)
+		if len(result.SourceRows) > 0 {
+			batch.Input.SourceRows[key] = result.SourceRows
+		}
+		batch.Pairs++
+	}
+
+	// Vinculações antes das remoções
+	for _, mode := range []OperationMode{ModeLink, ModeUnlink} {
+		if batch, ok := inputs[mode]; ok {
+			batch.Input.ProductCodes = distinctProducts(batch.Input.IBMToProducts)
+			plan.Batches = append(plan.Batches, *batch)
+		}
+	}
+
+	return plan
+}
+
+// Retry reprocessa os pares selecionados por PlanRetry e retorna o resultado anterior
+// atualizado: os resultados novos substituem as falhas reprocessadas e os demais
+// itens são mantidos.
+func (uc *ProcessProductsUseCase) Retry(ctx context.Context, previous *dto.ProcessProductsOutput, opts RetryOptions) (*dto.ProcessProductsOutput, error) {
+	plan := PlanRetry(previous, opts)
+	if plan.Skipped > 0 {
+		log.Printf("⚠️  %d falhas sem motivo reconhecido ou sem IBM/EAN não serão reprocessadas", plan.Skipped)
+	}
+	if plan.SyncRemovals > 0 {
+		log.Printf("⚠️  %d remoções da sincronização não serão reprocessadas: reexecute o sync com o sortimento completo", plan.SyncRemovals)
+	}
+	log.Printf("🔁 %d pares a reprocessar", plan.Pairs())
+
+	retried := make([]*dto.ProcessProductsOutput, 0, len(plan.Batches))
+	for _, batch := range plan.Batches {
+		log.Printf("🔁 Reprocessando %d pares no modo %s", batch.Pairs, batch.Mode)
+		output, err := uc.ExecuteWithOptions(ctx, batch.Input, ExecuteOptions{Mode: batch.Mode})
+		if err != nil {
+			return nil, fmt.Errorf("erro ao reprocessar pares no modo %s: %w", batch.Mode, err)
+		}
+		retried = append(retried, output)
+	}
+
+	return mergeRetry(previous, plan, retried), nil
+}
+
+// mergeRetry substitui, no resultado anterior, os pares reprocessados pelos novos resultados
+func mergeRetry(previous *dto.ProcessProductsOutput, plan RetryPlan, retried []*dto.ProcessProductsOutput) *dto.ProcessProductsOutput {
+	replaced := make(map[[2]string]bool)
+	for _, batch := range plan.Batches {
+		for ibmCode, eans := range batch.Input.IBMToProducts {
+			for _, ean := range eans {
+				replaced[[2]string{ibmCode, ean}] = true
+			}
+		}
+	}
+
+	merged := &dto.ProcessProductsOutput{
+		SchemaVersion:  dto.OutputSchemaVersion,
+		SuccessList:    []dto.ProductResultDTO{},
+		FailureList:    []dto.ProductResultDTO{},
+		Mode:           previous.Mode,
+		UnresolvedEANs: previous.UnresolvedEANs,
+		InvalidEANs:    previous.InvalidEANs,
+	}
+	sink := memorySink{output: merged}
+	add := func(result dto.ProductResultDTO) {
+		countResult(&merged.Totals, result)
+		sink.Write(result)
+	}
+
+	for _, result := range previous.SuccessList {
+		add(result)
+	}
+	for _, result := range retryCandidates(previous) {
+		// Somente falhas selecionadas são substituídas: um par repetido com outro
+		// motivo (fora do filtro) continua no resultado
+		if replaced[[2]string{result.IBM, result.EAN}] && slices.Contains(plan.reasons, retryReasonCode(result)) {
+			continue
+		}
+		add(result)
+	}
+	for _, output := range retried {
+		for _, list := range [][]dto.ProductResultDTO{output.SuccessList, output.FailureList, output.NotProcessedList} {
+			for _, result := range list {
+				add(result)
+			}
+		}
+		merged.Cancelled = merged.Cancelled || output.Cancelled
+		merged.UnresolvedEANs = mergeSorted(merged.UnresolvedEANs, output.UnresolvedEANs)
+		merged.InvalidEANs = mergeSorted(merged.InvalidEANs, output.InvalidEANs)
+	}
+	merged.Cancelled = merged.Cancelled || len(merged.NotProcessedList) > 0
+
+	return merged
+}
+
+// retryCandidates retorna os itens do resultado anterior que podem ser reprocessados
+func retryCandidates(previous *dto.ProcessProductsOutput) []dto.ProductResultDTO {
+	candidates := make([]dto.ProductResultDTO, 0, len(previous.FailureList)+len(previous.NotProcessedList))
+	candidates = append(candidates, previous.FailureList...)
+	return append(candidates, previous.NotProcessedList...)
+}
+
+// legacyReasonCodes traduz os motivos fixos dos resultados da versão 1, que não têm CodigoMotivo
+var legacyReasonCodes = map[string]string{
+	"Produto não encontrado pelo EAN":                    dto.ReasonEANNotFound,
+	"EAN associado a mais de um produto":                 dto.ReasonAmbiguousEAN,
+	"Revendedor não encontrado":                          dto.ReasonDealerNotFound,
+	"Erro ao criar relação produto-revendedor (batch)":   dto.ReasonRelationCheckError,
+	"Erro ao remover relação produto-revendedor (batch)": dto.ReasonRelationRemoveError,
+	"Relação produto-revendedor não encontrada":          dto.ReasonRelationNotFound,
+	"Erro ao gravar integração produto staging":          dto.ReasonStagingSPError,
+	"Erro ao verificar integração produto staging":       dto.ReasonStagingCheckError,
+	"Registro não encontrado após chamada da procedure":  dto.ReasonStagingNotVisible,
+	"Processamento cancelado antes da conclusão":         dto.ReasonCancelled,
+}
+
+// retryReasonCode retorna o código de motivo do item. Nos resultados da versão 1 o
+// código é deduzido do texto do motivo; itens não processados só podem ter sido cancelados.
+func retryReasonCode(result dto.ProductResultDTO) string {
+	switch {
+	case result.ReasonCode != "":
+		return result.ReasonCode
+	case result.Status == statusNotProcessed:
+		return dto.ReasonCancelled
+	}
+	return legacyReasonCodes[result.Reason]
+}
+
+// isRemoval indica se a falha é de um par que a sincronização removia (resultados
+// gravados antes de RemocaoSincronizacao)
+func isRemoval(result dto.ProductResultDTO) bool {
+	switch result.Relation {
+	case dto.RelationDeactivated, dto.RelationDeleted, dto.RelationAlreadyInactive:
+		return true
+	}
+	return retryReasonCode(result) == dto.ReasonRelationRemoveError
+}
+
+// distinctProducts retorna os códigos de produto distintos da entrada, ordenados
+func distinctProducts(ibmToProducts map[string][]string) []string {
+	seen := make(map[string]bool)
+	var products []string
+	for _, codes := range ibmToProducts {
+		for _, code := range codes {
+			if !seen[code] {
+				seen[code] = true
+				products = append(products, code)
+			}
+		}
+	}
+	sort.Strings(products)
+	return products
+}
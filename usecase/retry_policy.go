@@ -0,0 +1,98 @@
+package usecase
+
+import (
+	"context"
+	"log"
+	"math/rand/v2"
+	"time"
+)
+
+// RetryPolicy define as novas tentativas das operações de banco de cada par (procedure
+// de staging, consulta de confirmação e batches de relações) que falham por erro
+// transitório, como ORA-03113 ou conexão reiniciada
+type RetryPolicy struct {
+	MaxAttempts int           // Tentativas por operação, incluindo a primeira (1 = sem novas tentativas)
+	BaseDelay   time.Duration // Espera antes da segunda tentativa; dobra a cada nova tentativa
+	MaxDelay    time.Duration // Limite da espera entre tentativas
+	Budget      int           // Novas tentativas permitidas na execução inteira (0 = sem limite)
+}
+
+// DefaultRetryPolicy é a política usada quando nenhuma é configurada
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   200 * time.Millisecond,
+	MaxDelay:    5 * time.Second,
+	Budget:      500,
+}
+
+// SetRetryPolicy define a política de novas tentativas para erros transitórios do banco
+func (uc *ProcessProductsUseCase) SetRetryPolicy(policy RetryPolicy) {
+	if policy.MaxAttempts < 1 || policy.BaseDelay < 0 || policy.Budget < 0 {
+		return
+	}
+	if policy.MaxDelay < policy.BaseDelay {
+		policy.MaxDelay = policy.BaseDelay
+	}
+	uc.retryPolicy = policy
+}
+
+// backoff retorna a espera antes da próxima tentativa: exponencial a partir de
+// BaseDelay, limitada a MaxDelay, com jitter para que os workers não repitam juntos
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	// A espera é dobrada comparando com MaxDelay antes de cada multiplicação, sem overflow
+	// mesmo com muitas tentativas
+	delay := min(p.BaseDelay, p.MaxDelay)
+	for i := 1; i < attempt && delay > 0 && delay < p.MaxDelay; i++ {
+		if delay > p.MaxDelay/2 {
+			delay = p.MaxDelay
+			break
+		}
+		delay *= 2
+	}
+	if delay <= 0 {
+		return 0
+	}
+	// Entre metade e o total da espera calculada
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// withRetry executa a operação repetindo-a, conforme a política, enquanto falhar por
+// erro transitório. Retorna o número de tentativas feitas e o erro da última.
+func (r *processRun) withRetry(ctx context.Context, operation string, op func(context.Context) error) (int, error) {
+	policy := r.uc.retryPolicy
+	for attempt := 1; ; attempt++ {
+		err := op(ctx)
+		if err == nil || attempt >= policy.MaxAttempts || ctx.Err() != nil || !isTransientError(err) {
+			return attempt, err
+		}
+		if !r.takeRetry() {
+			return attempt, err
+		}
+
+		delay := policy.backoff(attempt)
+		log.Printf("🔁 %s: erro transitório (%v), tentativa %d de %d em %s", operation, err, attempt+1, policy.MaxAttempts, delay.Round(time.Millisecond))
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return attempt, err
+		case <-timer.C:
+		}
+	}
+}
+
+// takeRetry consome uma nova tentativa do orçamento da execução. Retorna false
+// quando o orçamento acabou.
+func (r *processRun) takeRetry() bool {
+	budget := int64(r.uc.retryPolicy.Budget)
+	used := r.retries.Add(1)
+	if budget > 0 && used > budget {
+		r.retries.Add(-1)
+		if r.retryBudgetExhausted.CompareAndSwap(false, true) {
+			log.Printf("⚠️  Orçamento de %d novas tentativas esgotado: as próximas falhas transitórias não serão repetidas", budget)
+		}
+		return false
+	}
+	return true
+}
@@ -0,0 +1,60 @@
+package usecase
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  RetryPolicy
+		attempt int
+		max     time.Duration // A espera fica entre max/2 e max
+	}{
+		{name: "primeira tentativa", policy: RetryPolicy{BaseDelay: 200 * time.Millisecond, MaxDelay: 5 * time.Second}, attempt: 1, max: 200 * time.Millisecond},
+		{name: "dobra a cada tentativa", policy: RetryPolicy{BaseDelay: 200 * time.Millisecond, MaxDelay: 5 * time.Second}, attempt: 3, max: 800 * time.Millisecond},
+		{name: "limitada a MaxDelay", policy: RetryPolicy{BaseDelay: 200 * time.Millisecond, MaxDelay: 5 * time.Second}, attempt: 10, max: 5 * time.Second},
+		{name: "muitas tentativas sem overflow", policy: RetryPolicy{BaseDelay: 200 * time.Millisecond, MaxDelay: 5 * time.Second}, attempt: 200, max: 5 * time.Second},
+		{name: "BaseDelay alto sem overflow", policy: RetryPolicy{BaseDelay: time.Hour, MaxDelay: 24 * time.Hour}, attempt: 30, max: 24 * time.Hour},
+		{name: "MaxDelay máximo sem overflow", policy: RetryPolicy{BaseDelay: time.Second, MaxDelay: math.MaxInt64}, attempt: 100, max: math.MaxInt64},
+		{name: "BaseDelay acima de MaxDelay", policy: RetryPolicy{BaseDelay: 10 * time.Second, MaxDelay: time.Second}, attempt: 1, max: time.Second},
+		{name: "sem espera", policy: RetryPolicy{}, attempt: 5, max: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for range 100 {
+				got := tt.policy.backoff(tt.attempt)
+				if got < tt.max/2 || got > tt.max {
+					t.Fatalf("backoff(%d) = %s, esperado entre %s e %s", tt.attempt, got, tt.max/2, tt.max)
+				}
+			}
+		})
+	}
+}
+
+func TestSetRetryPolicy(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy RetryPolicy
+		want   RetryPolicy
+	}{
+		{name: "válida", policy: RetryPolicy{MaxAttempts: 5, BaseDelay: time.Second, MaxDelay: time.Minute}, want: RetryPolicy{MaxAttempts: 5, BaseDelay: time.Second, MaxDelay: time.Minute}},
+		{name: "MaxDelay abaixo de BaseDelay", policy: RetryPolicy{MaxAttempts: 2, BaseDelay: time.Second}, want: RetryPolicy{MaxAttempts: 2, BaseDelay: time.Second, MaxDelay: time.Second}},
+		{name: "sem tentativas é ignorada", policy: RetryPolicy{MaxAttempts: 0}, want: DefaultRetryPolicy},
+		{name: "espera negativa é ignorada", policy: RetryPolicy{MaxAttempts: 2, BaseDelay: -time.Second}, want: DefaultRetryPolicy},
+		{name: "orçamento negativo é ignorado", policy: RetryPolicy{MaxAttempts: 2, Budget: -1}, want: DefaultRetryPolicy},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := NewProcessProductsUseCase(nil, nil, nil, nil, nil)
+			uc.SetRetryPolicy(tt.policy)
+			if uc.retryPolicy != tt.want {
+				t.Errorf("retryPolicy = %+v, esperado %+v", uc.retryPolicy, tt.want)
+			}
+		})
+	}
+}
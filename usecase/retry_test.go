@@ -0,0 +1,198 @@
+package usecase
+
+import (
+	"slices"
+	"testing"
+
+	"github.thiagohmm.com.br/cargaparcial/usecase/dto"
+)
+
+func failure(ibm, ean, code string) dto.ProductResultDTO {
+	return dto.ProductResultDTO{IBM: ibm, EAN: ean, Status: "fail", ReasonCode: code}
+}
+
+func TestPlanRetry(t *testing.T) {
+	type batch struct {
+		mode  OperationMode
+		pairs map[string][]string
+	}
+	tests := []struct {
+		name         string
+		previous     *dto.ProcessProductsOutput
+		opts         RetryOptions
+		want         []batch
+		skipped      int
+		syncRemovals int
+	}{
+		{
+			name: "motivos padrão",
+			previous: &dto.ProcessProductsOutput{
+				Mode: string(ModeLink),
+				FailureList: []dto.ProductResultDTO{
+					failure("1", "A", dto.ReasonStagingSPError),
+					failure("1", "B", dto.ReasonEANNotFound),
+					failure("2", "A", dto.ReasonRelationCheckError),
+				},
+				NotProcessedList: []dto.ProductResultDTO{
+					{IBM: "2", EAN: "C", Status: statusNotProcessed},
+				},
+			},
+			want: []batch{{mode: ModeLink, pairs: map[string][]string{"1": {"A"}, "2": {"A", "C"}}}},
+		},
+		{
+			name: "motivos informados",
+			previous: &dto.ProcessProductsOutput{
+				Mode: string(ModeLink),
+				FailureList: []dto.ProductResultDTO{
+					failure("1", "A", dto.ReasonStagingSPError),
+					failure("1", "B", dto.ReasonEANNotFound),
+				},
+			},
+			opts: RetryOptions{Reasons: []string{dto.ReasonEANNotFound}},
+			want: []batch{{mode: ModeLink, pairs: map[string][]string{"1": {"B"}}}},
+		},
+		{
+			name: "sync refaz vinculações e não reprocessa remoções",
+			previous: &dto.ProcessProductsOutput{
+				Mode: string(ModeSync),
+				FailureList: []dto.ProductResultDTO{
+					failure("1", "A", dto.ReasonRelationRemoveError),
+					failure("1", "B", dto.ReasonStagingSPError),
+					{IBM: "1", EAN: "C", Status: "fail", ReasonCode: dto.ReasonStagingSPError, Relation: dto.RelationDeactivated},
+					failure("1", "E", dto.ReasonEANNotFound),
+				},
+				NotProcessedList: []dto.ProductResultDTO{
+					{IBM: "1", EAN: "D", Status: statusNotProcessed, SyncRemoval: true},
+				},
+			},
+			want:         []batch{{mode: ModeLink, pairs: map[string][]string{"1": {"B"}}}},
+			syncRemovals: 3,
+		},
+		{
+			name: "pares repetidos e falhas sem IBM, EAN ou motivo",
+			previous: &dto.ProcessProductsOutput{
+				Mode: string(ModeUnlink),
+				FailureList: []dto.ProductResultDTO{
+					failure("1", "A", dto.ReasonRelationRemoveError),
+					failure("1", "A", dto.ReasonRelationRemoveError),
+					failure("", "B", dto.ReasonRelationRemoveError),
+					failure("1", "", dto.ReasonRelationRemoveError),
+					{IBM: "1", EAN: "D", Status: "fail", Reason: "motivo desconhecido"},
+				},
+			},
+			want:    []batch{{mode: ModeUnlink, pairs: map[string][]string{"1": {"A"}}}},
+			skipped: 3,
+		},
+		{
+			name: "resultado da versão 1 sem código de motivo",
+			previous: &dto.ProcessProductsOutput{
+				FailureList: []dto.ProductResultDTO{
+					{IBM: "1", EAN: "A", Status: "fail", Reason: "Erro ao gravar integração produto staging"},
+					{IBM: "1", EAN: "B", Status: "fail", Reason: "Produto não encontrado pelo EAN"},
+				},
+			},
+			want: []batch{{mode: ModeLink, pairs: map[string][]string{"1": {"A"}}}},
+		},
+		{
+			name:     "nada a reprocessar",
+			previous: &dto.ProcessProductsOutput{Mode: string(ModeLink), FailureList: []dto.ProductResultDTO{failure("1", "A", dto.ReasonDealerNotFound)}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := PlanRetry(tt.previous, tt.opts)
+			if plan.Skipped != tt.skipped {
+				t.Errorf("Skipped = %d, esperado %d", plan.Skipped, tt.skipped)
+			}
+			if plan.SyncRemovals != tt.syncRemovals {
+				t.Errorf("SyncRemovals = %d, esperado %d", plan.SyncRemovals, tt.syncRemovals)
+			}
+			if len(plan.Batches) != len(tt.want) {
+				t.Fatalf("%d batches, esperado %d", len(plan.Batches), len(tt.want))
+			}
+
+			pairs := 0
+			for i, want := range tt.want {
+				got := plan.Batches[i]
+				if got.Mode != want.mode {
+					t.Errorf("batch %d no modo %s, esperado %s", i, got.Mode, want.mode)
+				}
+				count := 0
+				for ibm, eans := range want.pairs {
+					if !slices.Equal(got.Input.IBMToProducts[ibm], eans) {
+						t.Errorf("batch %d: IBM %s com %v, esperado %v", i, ibm, got.Input.IBMToProducts[ibm], eans)
+					}
+					count += len(eans)
+				}
+				if len(got.Input.IBMToProducts) != len(want.pairs) || got.Pairs != count {
+					t.Errorf("batch %d: %d IBMs e %d pares, esperado %d e %d", i, len(got.Input.IBMToProducts), got.Pairs, len(want.pairs), count)
+				}
+				pairs += count
+			}
+			if plan.Pairs() != pairs {
+				t.Errorf("Pairs() = %d, esperado %d", plan.Pairs(), pairs)
+			}
+		})
+	}
+}
+
+func TestMergeRetry(t *testing.T) {
+	previous := &dto.ProcessProductsOutput{
+		Mode:        string(ModeLink),
+		SuccessList: []dto.ProductResultDTO{{IBM: "1", EAN: "A", Status: "ok"}},
+		FailureList: []dto.ProductResultDTO{
+			failure("1", "B", dto.ReasonStagingSPError),
+			failure("1", "C", dto.ReasonEANNotFound),
+		},
+		NotProcessedList: []dto.ProductResultDTO{{IBM: "1", EAN: "D", Status: statusNotProcessed}},
+		InvalidEANs:      []string{"124"},
+		Cancelled:        true,
+	}
+	plan := PlanRetry(previous, RetryOptions{})
+
+	retried := []*dto.ProcessProductsOutput{{
+		SuccessList: []dto.ProductResultDTO{{IBM: "1", EAN: "B", Status: "ok"}},
+		FailureList: []dto.ProductResultDTO{failure("1", "D", dto.ReasonStagingNotVisible)},
+		InvalidEANs: []string{"125"},
+	}}
+
+	merged := mergeRetry(previous, plan, retried)
+	totals := merged.Totals
+	if totals.Ok != 2 || totals.Fail != 2 || totals.NotProcessed != 0 {
+		t.Errorf("totais %+v, esperado 2 ok e 2 falhas", totals)
+	}
+	if totals.Ok != len(merged.SuccessList) || totals.Fail != len(merged.FailureList) || totals.NotProcessed != len(merged.NotProcessedList) {
+		t.Errorf("totais %+v não batem com as listas", totals)
+	}
+	if merged.Cancelled {
+		t.Error("resultado reprocessado sem pendências não deveria estar cancelado")
+	}
+	if merged.SchemaVersion != dto.OutputSchemaVersion || merged.Mode != string(ModeLink) {
+		t.Errorf("versão %d e modo %q, esperado %d e %q", merged.SchemaVersion, merged.Mode, dto.OutputSchemaVersion, ModeLink)
+	}
+	if !slices.Equal(merged.InvalidEANs, []string{"124", "125"}) {
+		t.Errorf("InvalidEANs = %v", merged.InvalidEANs)
+	}
+
+	results := make(map[string]dto.ProductResultDTO)
+	for _, list := range [][]dto.ProductResultDTO{merged.SuccessList, merged.FailureList} {
+		for _, result := range list {
+			if _, dup := results[result.EAN]; dup {
+				t.Errorf("EAN %s com mais de um resultado", result.EAN)
+			}
+			results[result.EAN] = result
+		}
+	}
+	want := map[string]string{"A": "ok", "B": "ok", "C": dto.ReasonEANNotFound, "D": dto.ReasonStagingNotVisible}
+	for ean, expected := range want {
+		result := results[ean]
+		got := result.ReasonCode
+		if expected == "ok" {
+			got = result.Status
+		}
+		if got != expected {
+			t.Errorf("EAN %s: %q, esperado %q", ean, got, expected)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+
+	"github.thiagohmm.com.br/cargaparcial/usecase/dto"
+)
+
+// streamChunkSize é o número de pares lidos da entrada por bloco de processamento
+const streamChunkSize = 10000
+
+// PairReader fornece os pares IBM/EAN da entrada um a um, sem carregar o arquivo inteiro
+type PairReader interface {
+	// Next retorna o próximo par ou io.EOF ao final da entrada
+	Next() (ibm, ean string, err error)
+}
+
+// ExecuteStream processa os pares lidos do reader em blocos de streamChunkSize,
+// de modo que o volume de jobs em memória não depende do tamanho da entrada.
+// Dealers e EANs são resolvidos por bloco; o cache de dealers é aproveitado entre blocos.
+// Após o cancelamento, os pares restantes da entrada são listados em NotProcessedList.
+func (uc *ProcessProductsUseCase) ExecuteStream(ctx context.Context, reader PairReader, opts ExecuteOptions) (*dto.ProcessProductsOutput, error) {
+	// A sincronização compara o sortimento completo de cada loja, que um bloco não garante
+	if opts.Mode == ModeSync {
+		return nil, errors.New("o modo sync não é suportado com leitura em streaming")
+	}
+
+	run, err := uc.newProcessRun(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Iniciando processamento em streaming com %d workers (blocos de %d pares)", uc.maxWorkers, streamChunkSize)
+
+	output := &dto.ProcessProductsOutput{
+		SchemaVersion: dto.OutputSchemaVersion,
+		SuccessList:   []dto.ProductResultDTO{},
+		FailureList:   []dto.ProductResultDTO{},
+		DryRun:        opts.DryRun,
+		Mode:          string(run.mode()),
+	}
+
+	for chunk := 1; ; chunk++ {
+		input, err := readChunk(reader, streamChunkSize)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler entrada: %w", err)
+		}
+		if len(input.IBMCodes) == 0 {
+			break
+		}
+
+		log.Printf("📦 Bloco %d: %d IBMs, %d produtos", chunk, len(input.IBMCodes), len(input.ProductCodes))
+		if err := run.process(ctx, input, output); err != nil {
+			return nil, err
+		}
+	}
+
+	run.finish(ctx, output)
+	return output, nil
+}
+
+// readChunk lê até size pares do reader e monta a entrada do bloco com o
+// relacionamento IBM → Produtos. Uma entrada vazia indica o fim do reader.
+func readChunk(reader PairReader, size int) (dto.ProcessProductsInput, error) {
+	input := dto.ProcessProductsInput{IBMToProducts: make(map[string][]string)}
+	seenProducts := make(map[string]bool)
+
+	for pairs := 0; pairs < size; pairs++ {
+		ibm, ean, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return dto.ProcessProductsInput{}, err
+		}
+
+		if _, exists := input.IBMToProducts[ibm]; !exists {
+			input.IBMCodes = append(input.IBMCodes, ibm)
+		}
+		input.IBMToProducts[ibm] = append(input.IBMToProducts[ibm], ean)
+
+		if !seenProducts[ean] {
+			seenProducts[ean] = true
+			input.ProductCodes = append(input.ProductCodes, ean)
+		}
+	}
+
+	return input, nil
+}
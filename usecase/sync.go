@@ -0,0 +1,150 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.thiagohmm.com.br/cargaparcial/domain/entities"
+	"github.thiagohmm.com.br/cargaparcial/usecase/dto"
+)
+
+// DefaultSyncMaxRemoval é o percentual máximo padrão de relações ativas de uma loja
+// que uma sincronização pode remover
+const DefaultSyncMaxRemoval = 20.0
+
+// ErrSyncRemovalLimit indica que a sincronização removeria mais relações do que o limite configurado
+var ErrSyncRemovalLimit = errors.New("sincronização abortada: limite de remoção excedido")
+
+// SetSyncMaxRemoval define o percentual máximo (0 a 100) das relações ativas de uma loja
+// que o modo sync pode remover. Acima dele a execução é abortada antes de gravar.
+func (uc *ProcessProductsUseCase) SetSyncMaxRemoval(percent float64) {
+	if percent >= 0 && percent <= 100 {
+		uc.syncMaxRemoval = percent
+	}
+}
+
+// planSync compara, para cada loja da entrada, os produtos do arquivo com as relações
+// ativas no banco. Guarda as relações ativas (para não reenviar pares inalterados) e
+// retorna os pares a remover. Nenhuma alteração é feita se alguma loja ultrapassar
+// o limite de remoção.
+func (r *processRun) planSync(ctx context.Context, input dto.ProcessProductsInput, dealerMap map[string]*entities.Dealer) ([]resolvedPair, error) {
+	dealerIDs := make([]int, 0, len(dealerMap))
+	for _, dealer := range dealerMap {
+		dealerIDs = append(dealerIDs, dealer.ID)
+	}
+	sort.Ints(dealerIDs)
+
+	activeByDealer, err := r.uc.productDealerRepo.ListActiveProducts(ctx, dealerIDs)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar relações ativas para sincronização: %w", err)
+	}
+
+	ibmCodes := make([]string, 0, len(dealerMap))
+	for ibmCode := range dealerMap {
+		ibmCodes = append(ibmCodes, ibmCode)
+	}
+	sort.Strings(ibmCodes)
+
+	r.syncActive = make(map[int]map[int]bool, len(dealerMap))
+	var removals []resolvedPair
+	var violations []string
+
+	for _, ibmCode := range ibmCodes {
+		dealer := dealerMap[ibmCode]
+
+		active := make(map[int]bool, len(activeByDealer[dealer.ID]))
+		for _, product := range activeByDealer[dealer.ID] {
+			active[product.ID] = true
+		}
+		r.syncActive[dealer.ID] = active
+
+		// Todos os candidatos de um EAN ambíguo são mantidos, mesmo os que a
+		// política não vincula: a entrada não indica que devam sair da loja
+		kept := make(map[int]bool)
+		toLink := 0
+		for _, productCode := range productsForIBM(input, ibmCode) {
+			ean, err := r.uc.gtinOptions.Normalize(productCode)
+			if err != nil {
+				continue
+			}
+			products := r.productsByEAN[ean]
+			for _, product := range products {
+				kept[product.ID] = true
+			}
+			if selected, _ := r.uc.ambiguityPolicy.selectProducts(products); len(selected) > 0 && !allActive(active, selected) {
+				toLink++
+			}
+		}
+
+		var extra []entities.Product
+		for _, product := range activeByDealer[dealer.ID] {
+			if !kept[product.ID] {
+				extra = append(extra, product)
+			}
+		}
+
+		log.Printf("🔄 IBM %s: %d relações ativas, %d pares a vincular, %d relações a remover", ibmCode, len(active), toLink, len(extra))
+
+		if len(active) > 0 {
+			percent := float64(len(extra)) / float64(len(active)) * 100
+			if percent > r.uc.syncMaxRemoval {
+				violations = append(violations, fmt.Sprintf("IBM %s removeria %d de %d relações ativas (%.1f%%)", ibmCode, len(extra), len(active), percent))
+				continue
+			}
+		}
+
+		for _, product := range extra {
+			removals = append(removals, resolvedPair{
+				job:        JobInput{Dealer: dealer, ProductCode: product.EAN, Removal: true},
+				productIDs: []int{product.ID},
+				remove:     true,
+			})
+		}
+	}
+
+	if len(violations) > 0 {
+		return nil, fmt.Errorf("%w (%.1f%% por loja): %s", ErrSyncRemovalLimit, r.uc.syncMaxRemoval, strings.Join(violations, "; "))
+	}
+
+	return removals, nil
+}
+
+// unchanged indica se, na sincronização, todos os produtos do par já estão ativos na loja
+func (r *processRun) unchanged(pair resolvedPair) bool {
+	if r.mode() != ModeSync || pair.remove {
+		return false
+	}
+	return allActive(r.syncActive[pair.job.Dealer.ID], pair.productIDs)
+}
+
+// allActive indica se todos os produtos informados estão no conjunto de ativos
+func allActive(active map[int]bool, productIDs []int) bool {
+	for _, productID := range productIDs {
+		if !active[productID] {
+			return false
+		}
+	}
+	return true
+}
+
+// unchangedResult monta o resultado de um par que a sincronização não precisa gravar
+func unchangedResult(pair resolvedPair, dryRun bool) dto.ProductResultDTO {
+	dealerID := pair.job.Dealer.ID
+	productID := pair.productIDs[0]
+	result := dto.ProductResultDTO{
+		DealerID:   &dealerID,
+		ProductID:  &productID,
+		Status:     "ok",
+		Candidates: pair.candidates,
+	}
+	if dryRun {
+		result.Planned = []string{dto.ActionAlreadyLinked}
+	} else {
+		result.Relation = dto.RelationAlreadyActive
+	}
+	return result
+}